@@ -0,0 +1,227 @@
+package dyfi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/jasonmoo/usgs/earthquake"
+)
+
+// names of the contents of a dyfi product
+const (
+	ZIPContent         = "cdi_zip.geojson"
+	Geo1kmContent      = "cdi_geo_1km.geojson"
+	Geo10kmContent     = "cdi_geo_10km.geojson"
+	AttenuationContent = "dyfi_plot_atten.json"
+)
+
+var ErrNoDYFI = errors.New("event has no dyfi")
+
+type (
+	// Location aggregates the DYFI responses of a ZIP code or geocoded cell.
+	// https://earthquake.usgs.gov/data/dyfi/background.php
+	Location struct {
+		// the ZIP/postal code or UTM cell name
+		Name    string
+		Place   string
+		Country string
+		// community determined intensity
+		CDI float64
+		// number of responses
+		Responses int
+		// hypocentral distance in km
+		Distance  float64
+		Latitude  float64
+		Longitude float64
+		// the location's outline as geojson, when provided
+		Geometry json.RawMessage
+	}
+
+	// Attenuation is the intensity vs distance data of dyfi_plot_atten.json
+	Attenuation struct {
+		XLabel   string
+		YLabel   string
+		Datasets []Dataset
+	}
+
+	// a series of the attenuation plot, ie: all reported data, binned means,
+	// median or the predicted intensity of an attenuation relation
+	Dataset struct {
+		Name   string
+		Class  string
+		Points []Point
+	}
+
+	Point struct {
+		// distance in km
+		X float64
+		// intensity
+		Y float64
+		// standard deviation of binned data, when present
+		StdDev float64
+	}
+)
+
+// numbers are encoded as numbers or strings depending on the product version
+type number float64
+
+func (n *number) UnmarshalJSON(b []byte) error {
+	if string(b) == "null" {
+		return nil
+	}
+	if len(b) > 0 && b[0] == '"' {
+		var s string
+		if err := json.Unmarshal(b, &s); err != nil {
+			return err
+		}
+		if s == "" {
+			return nil
+		}
+		v, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return err
+		}
+		*n = number(v)
+		return nil
+	}
+	var v float64
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	*n = number(v)
+	return nil
+}
+
+// parse a cdi_zip.geojson, cdi_geo_1km.geojson or cdi_geo_10km.geojson document
+func ParseLocations(r io.Reader) ([]Location, error) {
+
+	var doc struct {
+		Features []struct {
+			ID         json.RawMessage `json:"id"`
+			Geometry   json.RawMessage `json:"geometry"`
+			Properties struct {
+				Name    string `json:"name"`
+				Place   string `json:"place"`
+				Country string `json:"country"`
+				CDI     number `json:"cdi"`
+				NResp   number `json:"nresp"`
+				Dist    number `json:"dist"`
+				Lat     number `json:"lat"`
+				Lon     number `json:"lon"`
+			} `json:"properties"`
+		} `json:"features"`
+	}
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	locations := make([]Location, len(doc.Features))
+	for i, f := range doc.Features {
+		p := f.Properties
+		locations[i] = Location{
+			Name:      p.Name,
+			Place:     p.Place,
+			Country:   p.Country,
+			CDI:       float64(p.CDI),
+			Responses: int(p.NResp),
+			Distance:  float64(p.Dist),
+			Latitude:  float64(p.Lat),
+			Longitude: float64(p.Lon),
+			Geometry:  f.Geometry,
+		}
+		if locations[i].Name == "" {
+			var id string
+			if json.Unmarshal(f.ID, &id) == nil {
+				locations[i].Name = id
+			}
+		}
+	}
+
+	return locations, nil
+
+}
+
+// parse a dyfi_plot_atten.json document
+func ParseAttenuation(r io.Reader) (*Attenuation, error) {
+
+	var doc struct {
+		XLabel   string `json:"xlabel"`
+		YLabel   string `json:"ylabel"`
+		Datasets []struct {
+			Name  string `json:"legend"`
+			Class string `json:"class"`
+			Data  []struct {
+				X      number `json:"x"`
+				Y      number `json:"y"`
+				StdDev number `json:"stdev"`
+			} `json:"data"`
+		} `json:"datasets"`
+	}
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	a := &Attenuation{
+		XLabel: doc.XLabel,
+		YLabel: doc.YLabel,
+	}
+	for _, ds := range doc.Datasets {
+		d := Dataset{Name: ds.Name, Class: ds.Class}
+		for _, p := range ds.Data {
+			d.Points = append(d.Points, Point{X: float64(p.X), Y: float64(p.Y), StdDev: float64(p.StdDev)})
+		}
+		a.Datasets = append(a.Datasets, d)
+	}
+
+	return a, nil
+
+}
+
+// the first dataset of the given class, or nil
+func (a *Attenuation) Dataset(class string) *Dataset {
+	for i := range a.Datasets {
+		if a.Datasets[i].Class == class {
+			return &a.Datasets[i]
+		}
+	}
+	return nil
+}
+
+func download(ctx context.Context, c *earthquake.Client, e *earthquake.EventDetail, name string) (*bytes.Buffer, error) {
+	p := e.PreferredProduct(earthquake.ProductTypeDyfi)
+	if p == nil {
+		return nil, ErrNoDYFI
+	}
+	if _, exists := p.Contents[name]; !exists {
+		return nil, fmt.Errorf("dyfi product has no %s", name)
+	}
+	var buf bytes.Buffer
+	if _, err := c.DownloadContentContext(ctx, p, name, &buf); err != nil {
+		return nil, err
+	}
+	return &buf, nil
+}
+
+// fetch and parse the locations of the preferred dyfi product of an event,
+// name is one of ZIPContent, Geo1kmContent or Geo10kmContent.
+func FetchLocations(ctx context.Context, c *earthquake.Client, e *earthquake.EventDetail, name string) ([]Location, error) {
+	buf, err := download(ctx, c, e, name)
+	if err != nil {
+		return nil, err
+	}
+	return ParseLocations(buf)
+}
+
+// fetch and parse the intensity vs distance data of the preferred dyfi product of an event.
+func FetchAttenuation(ctx context.Context, c *earthquake.Client, e *earthquake.EventDetail) (*Attenuation, error) {
+	buf, err := download(ctx, c, e, AttenuationContent)
+	if err != nil {
+		return nil, err
+	}
+	return ParseAttenuation(buf)
+}
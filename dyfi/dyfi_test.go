@@ -0,0 +1,110 @@
+package dyfi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jasonmoo/usgs/earthquake"
+)
+
+const testZIP = `{"type":"FeatureCollection","features":[
+{"type":"Feature","id":"94702","geometry":{"type":"Polygon","coordinates":[[[-122.3,37.8],[-122.2,37.8],[-122.2,37.9],[-122.3,37.8]]]},"properties":{"name":"94702","place":"Berkeley","country":"US","cdi":"4.1","nresp":"12","dist":"21","lat":"37.86","lon":"-122.28"}},
+{"type":"Feature","id":"UTM:(10S 0565 4189 10000)","geometry":null,"properties":{"cdi":2,"nresp":1,"dist":102.5,"lat":37.85,"lon":-121.9}}
+]}`
+
+const testAttenuation = `{"xlabel":"Hypocentral distance (km)","ylabel":"Intensity (MMI)","datasets":[
+{"class":"scatterplot1","legend":"All reported data","data":[{"x":21,"y":4.1},{"x":102.5,"y":2}]},
+{"class":"binned","legend":"Mean intensity","data":[{"x":25,"y":3.8,"stdev":0.4}]}
+]}`
+
+func TestParseLocations(t *testing.T) {
+
+	locations, err := ParseLocations(strings.NewReader(testZIP))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(locations) != 2 {
+		t.Fatalf("expected 2 locations, got %d", len(locations))
+	}
+
+	l := locations[0]
+	if l.Name != "94702" || l.Place != "Berkeley" || l.CDI != 4.1 || l.Responses != 12 || l.Distance != 21 || l.Latitude != 37.86 {
+		t.Errorf("unexpected location %+v", l)
+	}
+	if len(l.Geometry) == 0 {
+		t.Error("expected geometry")
+	}
+	if l := locations[1]; l.Name != "UTM:(10S 0565 4189 10000)" || l.Responses != 1 || l.Distance != 102.5 {
+		t.Errorf("unexpected location %+v", l)
+	}
+
+}
+
+func TestParseAttenuation(t *testing.T) {
+
+	a, err := ParseAttenuation(strings.NewReader(testAttenuation))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(a.Datasets) != 2 || a.XLabel != "Hypocentral distance (km)" {
+		t.Fatalf("unexpected attenuation %+v", a)
+	}
+	if d := a.Dataset("scatterplot1"); d == nil || len(d.Points) != 2 || d.Points[1].X != 102.5 {
+		t.Errorf("unexpected dataset %+v", d)
+	}
+	if d := a.Dataset("binned"); d == nil || d.Name != "Mean intensity" || d.Points[0].StdDev != 0.4 {
+		t.Errorf("unexpected dataset %+v", d)
+	}
+
+}
+
+func TestFetch(t *testing.T) {
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/" + ZIPContent:
+			w.Write([]byte(testZIP))
+		case "/" + AttenuationContent:
+			w.Write([]byte(testAttenuation))
+		}
+	}))
+	defer ts.Close()
+
+	e := &earthquake.EventDetail{}
+	if _, err := FetchAttenuation(context.Background(), earthquake.NewClient(), e); err != ErrNoDYFI {
+		t.Errorf("expected %v, got %v", ErrNoDYFI, err)
+	}
+
+	e.Properties.Products = map[earthquake.ProductType][]earthquake.Product{
+		earthquake.ProductTypeDyfi: {{
+			ID: "dyfi",
+			Contents: map[string]earthquake.Content{
+				ZIPContent:         {URL: ts.URL + "/" + ZIPContent},
+				AttenuationContent: {URL: ts.URL + "/" + AttenuationContent},
+			},
+		}},
+	}
+
+	locations, err := FetchLocations(context.Background(), earthquake.NewClient(), e, ZIPContent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(locations) != 2 {
+		t.Errorf("expected 2 locations, got %d", len(locations))
+	}
+	if _, err := FetchLocations(context.Background(), earthquake.NewClient(), e, Geo1kmContent); err == nil {
+		t.Error("expected error for missing content")
+	}
+
+	a, err := FetchAttenuation(context.Background(), earthquake.NewClient(), e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(a.Datasets) != 2 {
+		t.Errorf("expected 2 datasets, got %d", len(a.Datasets))
+	}
+
+}
@@ -0,0 +1,255 @@
+package earthquake
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+type (
+	// Cache stores responses keyed by request url.  Implementations must be
+	// safe for concurrent use and must not modify entries after Set.
+	Cache interface {
+		Get(key string) (*CacheEntry, bool)
+		Set(key string, e *CacheEntry)
+		Delete(key string)
+	}
+
+	CacheEntry struct {
+		StatusCode int
+		Header     http.Header
+		Body       []byte
+		// when the entry was fetched or last revalidated
+		Stored time.Time
+	}
+)
+
+// endpoints cached by WithCache unless configured otherwise with WithCacheTTL
+var DefaultCacheTTL = map[string]time.Duration{
+	"/application.json": 24 * time.Hour,
+	"/application.wadl": 24 * time.Hour,
+	"/catalogs":         24 * time.Hour,
+	"/contributors":     24 * time.Hour,
+	"/version":          24 * time.Hour,
+}
+
+// cache responses in cache.  Entries younger than their endpoint's TTL are
+// served without a request, older ones are revalidated with If-None-Match and
+// If-Modified-Since when the service provided an ETag or Last-Modified header.
+func WithCache(cache Cache) Option {
+	return func(c *Client) {
+		c.cache = cache
+		if c.cacheTTL == nil {
+			c.cacheTTL = make(map[string]time.Duration)
+		}
+		for endpoint, ttl := range DefaultCacheTTL {
+			if _, exists := c.cacheTTL[endpoint]; !exists {
+				c.cacheTTL[endpoint] = ttl
+			}
+		}
+	}
+}
+
+// set the TTL of cached responses for endpoint, a path relative to the base url
+// such as "/catalogs" or an absolute url without query.  A ttl of 0 disables
+// caching of the endpoint.
+func WithCacheTTL(endpoint string, ttl time.Duration) Option {
+	return func(c *Client) {
+		if c.cacheTTL == nil {
+			c.cacheTTL = make(map[string]time.Duration)
+		}
+		c.cacheTTL[endpoint] = ttl
+	}
+}
+
+func (e *CacheEntry) response(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", e.StatusCode, http.StatusText(e.StatusCode)),
+		StatusCode:    e.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        e.Header.Clone(),
+		Body:          ioutil.NopCloser(bytes.NewReader(e.Body)),
+		ContentLength: int64(len(e.Body)),
+		Request:       req,
+	}
+}
+
+func (c *Client) cachedRoundTrip(req *http.Request, endpoint string, rt func(*http.Request) (*http.Response, error)) (*http.Response, error) {
+
+	ttl := c.cacheTTL[endpoint]
+	if c.cache == nil || ttl <= 0 || req.Method != http.MethodGet {
+		return rt(req)
+	}
+
+	key := req.URL.String()
+
+	e, cached := c.cache.Get(key)
+	if cached {
+		if time.Since(e.Stored) < ttl {
+			return e.response(req), nil
+		}
+		if etag := e.Header.Get("ETag"); etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		if lm := e.Header.Get("Last-Modified"); lm != "" {
+			req.Header.Set("If-Modified-Since", lm)
+		}
+	}
+
+	resp, err := rt(req)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case cached && resp.StatusCode == http.StatusNotModified:
+		resp.Body.Close()
+		ne := *e
+		ne.Stored = time.Now()
+		c.cache.Set(key, &ne)
+		return ne.response(req), nil
+
+	case resp.StatusCode == http.StatusOK:
+		body, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		c.cache.Set(key, &CacheEntry{
+			StatusCode: resp.StatusCode,
+			Header:     resp.Header.Clone(),
+			Body:       body,
+			Stored:     time.Now(),
+		})
+		resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+		return resp, nil
+	}
+
+	return resp, nil
+
+}
+
+// MemoryCache is an in-memory Cache holding up to size entries, evicting the
+// least recently used.
+type MemoryCache struct {
+	mu      sync.Mutex
+	size    int
+	ll      *list.List
+	entries map[string]*list.Element
+}
+
+type memoryCacheItem struct {
+	key string
+	e   *CacheEntry
+}
+
+func NewMemoryCache(size int) *MemoryCache {
+	return &MemoryCache{
+		size:    size,
+		ll:      list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+func (m *MemoryCache) Get(key string) (*CacheEntry, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	el, exists := m.entries[key]
+	if !exists {
+		return nil, false
+	}
+	m.ll.MoveToFront(el)
+	return el.Value.(*memoryCacheItem).e, true
+}
+
+func (m *MemoryCache) Set(key string, e *CacheEntry) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if el, exists := m.entries[key]; exists {
+		el.Value.(*memoryCacheItem).e = e
+		m.ll.MoveToFront(el)
+		return
+	}
+	m.entries[key] = m.ll.PushFront(&memoryCacheItem{key: key, e: e})
+	for m.size > 0 && m.ll.Len() > m.size {
+		el := m.ll.Back()
+		m.ll.Remove(el)
+		delete(m.entries, el.Value.(*memoryCacheItem).key)
+	}
+}
+
+func (m *MemoryCache) Delete(key string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if el, exists := m.entries[key]; exists {
+		m.ll.Remove(el)
+		delete(m.entries, key)
+	}
+}
+
+// DiskCache is a Cache storing one json file per entry in a directory.
+// Read and write failures are treated as cache misses.
+type DiskCache struct {
+	dir string
+}
+
+func NewDiskCache(dir string) (*DiskCache, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	return &DiskCache{dir: dir}, nil
+}
+
+func (d *DiskCache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(d.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+func (d *DiskCache) Get(key string) (*CacheEntry, bool) {
+	data, err := ioutil.ReadFile(d.path(key))
+	if err != nil {
+		return nil, false
+	}
+	var e CacheEntry
+	if err := json.Unmarshal(data, &e); err != nil {
+		return nil, false
+	}
+	return &e, true
+}
+
+func (d *DiskCache) Set(key string, e *CacheEntry) {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+	f, err := ioutil.TempFile(d.dir, "tmp-")
+	if err != nil {
+		return
+	}
+	_, err = f.Write(data)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return
+	}
+	if err := os.Rename(f.Name(), d.path(key)); err != nil {
+		os.Remove(f.Name())
+	}
+}
+
+func (d *DiskCache) Delete(key string) {
+	os.Remove(d.path(key))
+}
@@ -0,0 +1,108 @@
+package earthquake
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestMemoryCacheEviction(t *testing.T) {
+
+	m := NewMemoryCache(2)
+	m.Set("a", &CacheEntry{Body: []byte("a")})
+	m.Set("b", &CacheEntry{Body: []byte("b")})
+	m.Get("a")
+	m.Set("c", &CacheEntry{Body: []byte("c")})
+
+	if _, ok := m.Get("b"); ok {
+		t.Error("expected b to be evicted")
+	}
+	for _, key := range []string{"a", "c"} {
+		if e, ok := m.Get(key); !ok || string(e.Body) != key {
+			t.Errorf("expected %q to be cached", key)
+		}
+	}
+
+	m.Delete("a")
+	if _, ok := m.Get("a"); ok {
+		t.Error("expected a to be deleted")
+	}
+
+}
+
+func TestDiskCache(t *testing.T) {
+
+	d, err := NewDiskCache(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stored := time.Date(2019, 1, 2, 3, 4, 5, 0, time.UTC)
+	d.Set("key", &CacheEntry{StatusCode: 200, Header: http.Header{"Etag": {`"x"`}}, Body: []byte("body"), Stored: stored})
+
+	e, ok := d.Get("key")
+	if !ok {
+		t.Fatal("expected entry")
+	}
+	if string(e.Body) != "body" || e.Header.Get("ETag") != `"x"` || !e.Stored.Equal(stored) {
+		t.Errorf("unexpected entry %+v", e)
+	}
+
+	d.Delete("key")
+	if _, ok := d.Get("key"); ok {
+		t.Error("expected entry to be deleted")
+	}
+
+}
+
+func TestClientCache(t *testing.T) {
+
+	var requests, revalidated int
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			revalidated++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte("1.0"))
+	}, WithCache(NewMemoryCache(10)), WithCacheTTL("/version", time.Hour))
+
+	for i := 0; i < 3; i++ {
+		resp, err := c.GetVersion()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.Version != "1.0" {
+			t.Errorf("expected %q, got %q", "1.0", resp.Version)
+		}
+	}
+	if requests != 1 {
+		t.Errorf("expected 1 request, got %d", requests)
+	}
+
+	// expire the entry to force revalidation
+	c.cacheTTL["/version"] = time.Nanosecond
+	time.Sleep(time.Millisecond)
+
+	resp, err := c.GetVersion()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Version != "1.0" {
+		t.Errorf("expected %q, got %q", "1.0", resp.Version)
+	}
+	if revalidated != 1 {
+		t.Errorf("expected 1 revalidation, got %d", revalidated)
+	}
+
+	// uncached endpoints always hit the service
+	requests = 0
+	c.GetCount(NewQueryParameters())
+	c.GetCount(NewQueryParameters())
+	if requests != 2 {
+		t.Errorf("expected 2 requests, got %d", requests)
+	}
+
+}
@@ -0,0 +1,102 @@
+package earthquake
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// download the named content of p to w, ie: "download/grid.xml" of a shakemap.
+// The length and sha256 listed for the content are verified when present, but
+// note w will have received the data before a mismatch is reported.
+func (c *Client) DownloadContent(p *Product, name string, w io.Writer) (*Content, error) {
+	return c.DownloadContentContext(context.Background(), p, name, w)
+}
+
+func (c *Client) DownloadContentContext(ctx context.Context, p *Product, name string, w io.Writer) (*Content, error) {
+
+	content, exists := p.Contents[name]
+	if !exists {
+		return nil, fmt.Errorf("product %s has no content %q", p.ID, name)
+	}
+
+	var r io.Reader
+	if content.URL == "" {
+		r = strings.NewReader(content.Bytes)
+	} else {
+		resp, err := c.get(ctx, content.URL)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		r = resp.Body
+	}
+
+	h := sha256.New()
+	n, err := io.Copy(io.MultiWriter(w, h), r)
+	if err != nil {
+		return nil, err
+	}
+
+	if content.Length > 0 && n != content.Length {
+		return nil, fmt.Errorf("content %q: expected %d bytes, got %d", name, content.Length, n)
+	}
+	if content.Sha256 != "" {
+		if sum := hex.EncodeToString(h.Sum(nil)); !strings.EqualFold(sum, content.Sha256) {
+			return nil, fmt.Errorf("content %q: expected sha256 %s, got %s", name, content.Sha256, sum)
+		}
+	}
+
+	return &content, nil
+
+}
+
+// download the named content of p into dir, keeping its path within the product
+// ie: dir/download/grid.xml.  The file is only written once verified.  Returns
+// the path of the file.
+func (c *Client) DownloadContentToDir(p *Product, name, dir string) (string, error) {
+	return c.DownloadContentToDirContext(context.Background(), p, name, dir)
+}
+
+func (c *Client) DownloadContentToDirContext(ctx context.Context, p *Product, name, dir string) (string, error) {
+
+	// content names are relative slash separated paths, keep them inside dir
+	dst := filepath.Join(dir, filepath.FromSlash(path.Clean("/"+name)))
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return "", err
+	}
+
+	f, err := ioutil.TempFile(filepath.Dir(dst), ".download-")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(f.Name())
+
+	// TempFile creates the file 0600, give it the usual mode of a new file
+	if err := f.Chmod(0644); err != nil {
+		f.Close()
+		return "", err
+	}
+
+	_, err = c.DownloadContentContext(ctx, p, name, f)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.Rename(f.Name(), dst); err != nil {
+		return "", err
+	}
+
+	return dst, nil
+
+}
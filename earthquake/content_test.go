@@ -0,0 +1,80 @@
+package earthquake
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestDownloadContent(t *testing.T) {
+
+	const body = "<shakemap_grid/>"
+	sum := sha256.Sum256([]byte(body))
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(body))
+	}))
+	defer ts.Close()
+
+	p := &Product{
+		ID: "urn:usgs-product:us:shakemap:us2000ck9q:1",
+		Contents: map[string]Content{
+			"download/grid.xml": {ContentType: "application/xml", Length: int64(len(body)), URL: ts.URL + "/grid.xml", Sha256: hex.EncodeToString(sum[:])},
+			"bad/length.xml":    {Length: 1, URL: ts.URL + "/grid.xml"},
+			"bad/sha256.xml":    {URL: ts.URL + "/grid.xml", Sha256: "00"},
+			"inline.txt":        {Bytes: "inline"},
+		},
+	}
+
+	var buf bytes.Buffer
+	content, err := NewClient().DownloadContent(p, "download/grid.xml", &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != body || content.ContentType != "application/xml" {
+		t.Errorf("unexpected download %q %+v", buf.String(), content)
+	}
+
+	buf.Reset()
+	if _, err := NewClient().DownloadContent(p, "inline.txt", &buf); err != nil || buf.String() != "inline" {
+		t.Errorf("unexpected inline download %q %v", buf.String(), err)
+	}
+
+	for _, name := range []string{"bad/length.xml", "bad/sha256.xml", "missing.xml"} {
+		if _, err := NewClient().DownloadContent(p, name, ioutil.Discard); err == nil {
+			t.Errorf("%s: expected error, got none", name)
+		}
+	}
+
+	dir := t.TempDir()
+	dst, err := NewClient().DownloadContentToDir(p, "download/grid.xml", dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dst != filepath.Join(dir, "download", "grid.xml") {
+		t.Errorf("unexpected path %q", dst)
+	}
+	if data, err := ioutil.ReadFile(dst); err != nil || string(data) != body {
+		t.Errorf("unexpected file %q %v", data, err)
+	}
+	if fi, err := os.Stat(dst); err != nil {
+		t.Error(err)
+	} else if runtime.GOOS != "windows" && fi.Mode().Perm() != 0644 {
+		t.Errorf("expected mode 0644, got %v", fi.Mode().Perm())
+	}
+
+	if _, err := NewClient().DownloadContentToDir(p, "bad/sha256.xml", dir); err == nil {
+		t.Error("expected error, got none")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "bad", "sha256.xml")); !os.IsNotExist(err) {
+		t.Errorf("expected no file for failed download, got %v", err)
+	}
+
+}
@@ -0,0 +1,169 @@
+package earthquake
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// https://earthquake.usgs.gov/data/comcat/data-eventterms.php
+// time,latitude,longitude,depth,mag,magType,nst,gap,dmin,rms,net,id,updated,place,type,horizontalError,depthError,magError,magNst,status,locationSource,magSource
+type csvReader struct {
+	r    *csv.Reader
+	cols map[string]int
+}
+
+func newCSVReader(r io.Reader) (*csvReader, error) {
+	cr := csv.NewReader(r)
+	cr.ReuseRecord = true
+	header, err := cr.Read()
+	if err == io.EOF {
+		return &csvReader{r: cr}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	cols := make(map[string]int, len(header))
+	for i, name := range header {
+		cols[name] = i
+	}
+	return &csvReader{r: cr, cols: cols}, nil
+}
+
+// reads the next row as a Feature, returning io.EOF when done.
+func (cr *csvReader) Read() (*Feature, error) {
+
+	if cr.cols == nil {
+		return nil, io.EOF
+	}
+
+	record, err := cr.r.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	col := func(name string) string {
+		if i, ok := cr.cols[name]; ok && i < len(record) {
+			return record[i]
+		}
+		return ""
+	}
+
+	// the first failure is kept and reported for the row
+	var perr error
+	float := func(name string) *float64 {
+		s := col(name)
+		if s == "" {
+			return nil
+		}
+		v, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			if perr == nil {
+				perr = fmt.Errorf("csv column %s: %v", name, err)
+			}
+			return nil
+		}
+		return &v
+	}
+	integer := func(name string) *int {
+		s := col(name)
+		if s == "" {
+			return nil
+		}
+		v, err := strconv.Atoi(s)
+		if err != nil {
+			if perr == nil {
+				perr = fmt.Errorf("csv column %s: %v", name, err)
+			}
+			return nil
+		}
+		return &v
+	}
+	epoch := func(name string) UnixEpoch {
+		s := col(name)
+		if s == "" {
+			return UnixEpoch{}
+		}
+		t, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			if perr == nil {
+				perr = fmt.Errorf("csv column %s: %v", name, err)
+			}
+		}
+		return UnixEpoch{t}
+	}
+
+	f := &Feature{
+		ID:   col("id"),
+		Type: "Feature",
+		Geometry: Geometry{
+			Type: "Point",
+		},
+		Properties: Properties{
+			Dmin:            float("dmin"),
+			Gap:             float("gap"),
+			Mag:             float("mag"),
+			MagType:         col("magType"),
+			Net:             col("net"),
+			Nst:             integer("nst"),
+			Place:           col("place"),
+			Rms:             float("rms"),
+			Status:          col("status"),
+			Time:            epoch("time"),
+			Type:            col("type"),
+			Updated:         epoch("updated"),
+			DepthError:      float("depthError"),
+			HorizontalError: float("horizontalError"),
+			LocationSource:  col("locationSource"),
+			MagError:        float("magError"),
+			MagNst:          integer("magNst"),
+			MagSource:       col("magSource"),
+		},
+	}
+	f.Properties.Code = strings.TrimPrefix(f.ID, f.Properties.Net)
+
+	for _, name := range [...]string{"longitude", "latitude", "depth"} {
+		v := float(name)
+		if v == nil {
+			break
+		}
+		f.Geometry.Coordinates = append(f.Geometry.Coordinates, *v)
+	}
+
+	if perr != nil {
+		return nil, perr
+	}
+
+	return f, nil
+
+}
+
+func decodeCSV(r io.Reader) (*GetQueryResponse, error) {
+
+	cr, err := newCSVReader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	v := &GetQueryResponse{
+		Type:     "FeatureCollection",
+		Features: []Feature{},
+	}
+	for {
+		f, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		v.Features = append(v.Features, *f)
+	}
+	v.Metadata.Count = len(v.Features)
+
+	return v, nil
+
+}
@@ -0,0 +1,77 @@
+package earthquake
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testCSV = `time,latitude,longitude,depth,mag,magType,nst,gap,dmin,rms,net,id,updated,place,type,horizontalError,depthError,magError,magNst,status,locationSource,magSource
+2018-01-02T04:01:38.320Z,47.6045,-122.3456,22.05,1.07,ml,12,97,0.05211,0.15,uw,uw61362166,2018-01-02T19:18:37.490Z,"4km NNE of Bainbridge Island, Washington",earthquake,0.37,0.66,0.149,6,reviewed,uw,uw
+2018-01-02T03:55:52.080Z,-21.1179,-68.7707,117.92,4.3,mb,,104,0.789,0.87,us,us2000ck9q,2018-03-13T19:56:27.040Z,"52km SSE of Ollague, Chile",earthquake,10.4,7.9,0.119,20,reviewed,us,us
+`
+
+func TestDecodeCSV(t *testing.T) {
+
+	resp, err := decodeCSV(strings.NewReader(testCSV))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Features) != 2 {
+		t.Fatalf("expected 2 features, got %d", len(resp.Features))
+	}
+
+	f := resp.Features[0]
+	if f.ID != "uw61362166" {
+		t.Errorf("expected %q, got %q", "uw61362166", f.ID)
+	}
+	if f.Properties.Code != "61362166" {
+		t.Errorf("expected %q, got %q", "61362166", f.Properties.Code)
+	}
+	if f.Latitude() != 47.6045 || f.Longitude() != -122.3456 || f.Depth() != 22.05 {
+		t.Errorf("unexpected coordinates %v", f.Geometry.Coordinates)
+	}
+	expected := time.Date(2018, 1, 2, 4, 1, 38, 320e6, time.UTC)
+	if !f.Time().Equal(expected) {
+		t.Errorf("expected %v, got %v", expected, f.Time())
+	}
+	if f.Properties.Place != "4km NNE of Bainbridge Island, Washington" {
+		t.Errorf("unexpected place %q", f.Properties.Place)
+	}
+	if f.Properties.MagNst == nil || *f.Properties.MagNst != 6 {
+		t.Errorf("expected magNst 6, got %v", f.Properties.MagNst)
+	}
+	if resp.Features[1].Properties.Nst != nil {
+		t.Errorf("expected nil nst, got %v", *resp.Features[1].Properties.Nst)
+	}
+
+}
+
+func TestGetQueryCSV(t *testing.T) {
+
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if f := r.URL.Query().Get("format"); f != "csv" {
+			t.Errorf("expected format csv, got %q", f)
+		}
+		w.Header().Set("Content-Type", "text/csv")
+		w.Write([]byte(testCSV))
+	})
+
+	qp := NewQueryParameters()
+	qp.Format = FormatCSV
+
+	resp, err := c.GetQuery(qp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Features) != 2 {
+		t.Errorf("expected 2 features, got %d", len(resp.Features))
+	}
+
+	qp.Format = FormatKML
+	if _, err := c.GetQuery(qp); err == nil {
+		t.Error("expected error for kml format")
+	}
+
+}
@@ -0,0 +1,116 @@
+package earthquake
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+)
+
+type (
+	// the event detail geojson document linked by Properties.Detail, listing
+	// every product associated with the event.
+	EventDetail struct {
+		Geometry   Geometry              `json:"geometry"`
+		ID         string                `json:"id"`
+		Properties EventDetailProperties `json:"properties"`
+		Type       string                `json:"type"`
+	}
+
+	EventDetailProperties struct {
+		Properties
+		Products map[ProductType][]Product `json:"products"`
+	}
+
+	// https://earthquake.usgs.gov/data/comcat/data-eventterms.php#products
+	Product struct {
+		ID              string             `json:"id"`
+		Type            ProductType        `json:"type"`
+		Code            string             `json:"code"`
+		Source          string             `json:"source"`
+		Status          string             `json:"status"`
+		UpdateTime      UnixEpoch          `json:"updateTime"`
+		PreferredWeight int                `json:"preferredWeight"`
+		Properties      map[string]string  `json:"properties"`
+		Contents        map[string]Content `json:"contents"`
+	}
+
+	// a file attached to a product, keyed by its path within the product
+	// ie: "download/grid.xml"
+	Content struct {
+		ContentType  string    `json:"contentType"`
+		LastModified UnixEpoch `json:"lastModified"`
+		Length       int64     `json:"length"`
+		URL          string    `json:"url"`
+		// hex encoded, when provided by the service
+		Sha256 string `json:"sha256"`
+		// inline content, for products that embed it
+		Bytes string `json:"bytes"`
+	}
+)
+
+// product status of a deleted product
+const ProductStatusDelete = "DELETE"
+
+// the summary feature of the event
+func (e *EventDetail) Feature() Feature {
+	return Feature{
+		Geometry:   e.Geometry,
+		ID:         e.ID,
+		Properties: e.Properties.Properties,
+		Type:       e.Type,
+	}
+}
+
+// all products of type t, in the order listed by the service
+func (e *EventDetail) Products(t ProductType) []Product {
+	return e.Properties.Products[t]
+}
+
+// the preferred product of type t, the one with the highest preferred weight
+// and most recent update time that has not been deleted, or nil.
+func (e *EventDetail) PreferredProduct(t ProductType) *Product {
+	var preferred *Product
+	products := e.Properties.Products[t]
+	for i := range products {
+		p := &products[i]
+		if p.Status == ProductStatusDelete {
+			continue
+		}
+		if preferred == nil ||
+			p.PreferredWeight > preferred.PreferredWeight ||
+			p.PreferredWeight == preferred.PreferredWeight && p.UpdateTime.After(preferred.UpdateTime.Time) {
+			preferred = p
+		}
+	}
+	return preferred
+}
+
+// the url of the named content, or "" if the product has no such content
+func (p *Product) ContentURL(name string) string {
+	return p.Contents[name].URL
+}
+
+// request the detail document of a single event.
+func (c *Client) GetEventDetail(eventid string) (*EventDetail, error) {
+	return c.GetEventDetailContext(context.Background(), eventid)
+}
+
+func (c *Client) GetEventDetailContext(ctx context.Context, eventid string) (*EventDetail, error) {
+	// https://earthquake.usgs.gov/fdsnws/event/1/query?eventid=us2000ck9q&format=geojson
+	if eventid == "" {
+		// without an eventid the service returns the whole default feed
+		return nil, errors.New("eventid is required")
+	}
+	qp := NewQueryParameters()
+	qp.EventID = eventid
+	resp, err := c.get(ctx, "/query?"+qp.Encode())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var v EventDetail
+	if err := json.NewDecoder(resp.Body).Decode(&v); err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
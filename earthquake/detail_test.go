@@ -0,0 +1,69 @@
+package earthquake
+
+import (
+	"net/http"
+	"testing"
+)
+
+const testEventDetail = `{"type":"Feature","properties":{"mag":4.3,"place":"52km SSE of Ollague, Chile","time":1514865352080,"updated":1520970987040,"felt":null,"status":"reviewed","net":"us","code":"2000ck9q","ids":",us2000ck9q,","types":",origin,shakemap,","products":{
+"origin":[
+	{"id":"urn:usgs-product:us:origin:us2000ck9q:1520970987040","type":"origin","code":"us2000ck9q","source":"us","updateTime":1520970987040,"status":"UPDATE","properties":{"depth":"117.92","magnitude":"4.3"},"preferredWeight":156,"contents":{"quakeml.xml":{"contentType":"application/xml","lastModified":1520970986000,"length":2961,"url":"https://earthquake.usgs.gov/realtime/product/origin/us2000ck9q/us/1520970987040/quakeml.xml"}}},
+	{"id":"urn:usgs-product:pt:origin:pt18002000:1514865600000","type":"origin","code":"pt18002000","source":"pt","updateTime":1514865600000,"status":"UPDATE","properties":{},"preferredWeight":10,"contents":{}}
+],
+"shakemap":[
+	{"id":"a","type":"shakemap","code":"us2000ck9q","source":"us","updateTime":1514870000000,"status":"UPDATE","properties":{},"preferredWeight":100,"contents":{}},
+	{"id":"b","type":"shakemap","code":"us2000ck9q","source":"us","updateTime":1514880000000,"status":"UPDATE","properties":{},"preferredWeight":100,"contents":{"download/grid.xml":{"contentType":"application/xml","length":10,"url":"https://example.com/grid.xml","sha256":"abc"}}},
+	{"id":"c","type":"shakemap","code":"us2000ck9q","source":"us","updateTime":1514890000000,"status":"DELETE","properties":{},"preferredWeight":100,"contents":{}}
+]
+}},"geometry":{"type":"Point","coordinates":[-68.7707,-21.1179,117.92]},"id":"us2000ck9q"}`
+
+func TestGetEventDetail(t *testing.T) {
+
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("eventid") != "us2000ck9q" || q.Get("format") != "geojson" {
+			t.Errorf("unexpected query %q", r.URL.RawQuery)
+		}
+		w.Write([]byte(testEventDetail))
+	})
+
+	e, err := c.GetEventDetail("us2000ck9q")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if e.ID != "us2000ck9q" || e.Properties.Code != "2000ck9q" {
+		t.Errorf("unexpected event %q %q", e.ID, e.Properties.Code)
+	}
+	if f := e.Feature(); f.Properties.Mag == nil || *f.Properties.Mag != 4.3 || f.Depth() != 117.92 {
+		t.Errorf("unexpected feature %+v", f)
+	}
+
+	if n := len(e.Products(ProductTypeOrigin)); n != 2 {
+		t.Errorf("expected 2 origins, got %d", n)
+	}
+	origin := e.PreferredProduct(ProductTypeOrigin)
+	if origin == nil || origin.Source != "us" || origin.Properties["depth"] != "117.92" {
+		t.Fatalf("unexpected preferred origin %+v", origin)
+	}
+	if u := origin.ContentURL("quakeml.xml"); u != "https://earthquake.usgs.gov/realtime/product/origin/us2000ck9q/us/1520970987040/quakeml.xml" {
+		t.Errorf("unexpected content url %q", u)
+	}
+
+	shakemap := e.PreferredProduct(ProductTypeShakemap)
+	if shakemap == nil || shakemap.ID != "b" {
+		t.Fatalf("expected shakemap b, got %+v", shakemap)
+	}
+	if content := shakemap.Contents["download/grid.xml"]; content.Length != 10 || content.Sha256 != "abc" {
+		t.Errorf("unexpected content %+v", content)
+	}
+
+	if e.PreferredProduct(ProductTypeDyfi) != nil {
+		t.Error("expected no dyfi product")
+	}
+
+	if _, err := c.GetEventDetail(""); err == nil {
+		t.Error("expected error for empty eventid")
+	}
+
+}
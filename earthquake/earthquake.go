@@ -3,11 +3,14 @@ package earthquake
 //go:generate go run generate_constants.go
 
 import (
+	"context"
 	"encoding/json"
 	"encoding/xml"
 	"fmt"
 	"io/ioutil"
+	"math"
 	"net/http"
+	"net/url"
 	"path"
 	"strconv"
 	"strings"
@@ -18,8 +21,21 @@ type (
 	// https://earthquake.usgs.gov/fdsnws/event/1/
 	Client struct {
 		c *http.Client
+
+		baseURL   *url.URL
+		feedURL   *url.URL
+		transport http.RoundTripper
+		userAgent string
+		timeout   time.Duration
+		retry     RetryPolicy
+		limiter   *RateLimiter
+		cache     Cache
+		cacheTTL  map[string]time.Duration
 	}
 
+	// Option configures a Client created by NewClient.
+	Option func(*Client)
+
 	GetApplicationInfoResponse struct {
 		Catalogs       []Catalog       `json:"catalogs"`
 		Contributors   []Contributor   `json:"contributors"`
@@ -82,51 +98,72 @@ type (
 
 	GetQueryResponse struct {
 		Bbox     []float64 `json:"bbox"`
-		Features []struct {
-			Geometry struct {
-				Coordinates []float64 `json:"coordinates"`
-				Type        string    `json:"type"`
-			} `json:"geometry"`
-			ID         string `json:"id"`
-			Properties struct {
-				Alert   interface{} `json:"alert"`
-				Cdi     interface{} `json:"cdi"`
-				Code    string      `json:"code"`
-				Detail  string      `json:"detail"`
-				Dmin    float64     `json:"dmin"`
-				Felt    interface{} `json:"felt"`
-				Gap     int         `json:"gap"`
-				Ids     string      `json:"ids"`
-				Mag     float64     `json:"mag"`
-				MagType string      `json:"magType"`
-				Mmi     interface{} `json:"mmi"`
-				Net     string      `json:"net"`
-				Nst     int         `json:"nst"`
-				Place   string      `json:"place"`
-				Rms     float64     `json:"rms"`
-				Sig     int         `json:"sig"`
-				Sources string      `json:"sources"`
-				Status  string      `json:"status"`
-				Time    UnixEpoch   `json:"time"`
-				Title   string      `json:"title"`
-				Tsunami int         `json:"tsunami"`
-				Type    string      `json:"type"`
-				Types   string      `json:"types"`
-				Tz      int         `json:"tz"`
-				Updated UnixEpoch   `json:"updated"`
-				URL     string      `json:"url"`
-			} `json:"properties"`
-			Type string `json:"type"`
-		} `json:"features"`
-		Metadata struct {
-			API       string    `json:"api"`
-			Count     int       `json:"count"`
-			Generated UnixEpoch `json:"generated"`
-			Status    int       `json:"status"`
-			Title     string    `json:"title"`
-			URL       string    `json:"url"`
-		} `json:"metadata"`
-		Type string `json:"type"`
+		Features []Feature `json:"features"`
+		Metadata Metadata  `json:"metadata"`
+		Type     string    `json:"type"`
+	}
+
+	// a single event in a query result
+	Feature struct {
+		Geometry   Geometry   `json:"geometry"`
+		ID         string     `json:"id"`
+		Properties Properties `json:"properties"`
+		Type       string     `json:"type"`
+	}
+
+	// GeoJSON point as [longitude, latitude, depth]
+	Geometry struct {
+		Coordinates []float64 `json:"coordinates"`
+		Type        string    `json:"type"`
+	}
+
+	// numeric fields and alert are pointers as the service sends null when
+	// a value was never computed, ie: Felt is nil with no DYFI responses and
+	// non-nil zero when zero responses have been counted.
+	Properties struct {
+		Alert   *AlertLevel `json:"alert"`
+		Cdi     *float64    `json:"cdi"`
+		Code    string      `json:"code"`
+		Detail  string      `json:"detail"`
+		Dmin    *float64    `json:"dmin"`
+		Felt    *int        `json:"felt"`
+		Gap     *float64    `json:"gap"`
+		Ids     string      `json:"ids"`
+		Mag     *float64    `json:"mag"`
+		MagType string      `json:"magType"`
+		Mmi     *float64    `json:"mmi"`
+		Net     string      `json:"net"`
+		Nst     *int        `json:"nst"`
+		Place   string      `json:"place"`
+		Rms     *float64    `json:"rms"`
+		Sig     int         `json:"sig"`
+		Sources string      `json:"sources"`
+		Status  string      `json:"status"`
+		Time    UnixEpoch   `json:"time"`
+		Title   string      `json:"title"`
+		Tsunami int         `json:"tsunami"`
+		Type    string      `json:"type"`
+		Types   string      `json:"types"`
+		Tz      *int        `json:"tz"`
+		Updated UnixEpoch   `json:"updated"`
+		URL     string      `json:"url"`
+
+		// only present in csv results
+		DepthError      *float64 `json:"depthError,omitempty"`
+		HorizontalError *float64 `json:"horizontalError,omitempty"`
+		LocationSource  string   `json:"locationSource,omitempty"`
+		MagError        *float64 `json:"magError,omitempty"`
+		MagNst          *int     `json:"magNst,omitempty"`
+		MagSource       string   `json:"magSource,omitempty"`
+	}
+
+	Metadata struct {
+		API       string    `json:"api"`
+		Count     int       `json:"count"`
+		Generated UnixEpoch `json:"generated"`
+		Status    int       `json:"status"`
+		Title     string    `json:"title"`
+		URL       string    `json:"url"`
 	}
 
 	GetVersionResponse struct {
@@ -137,6 +174,10 @@ type (
 )
 
 func (e *UnixEpoch) UnmarshalJSON(b []byte) error {
+	if string(b) == "null" {
+		(*e).Time = time.Time{}
+		return nil
+	}
 	v, err := strconv.ParseInt(string(b), 10, 64)
 	if err != nil {
 		return err
@@ -145,48 +186,162 @@ func (e *UnixEpoch) UnmarshalJSON(b []byte) error {
 	return nil
 }
 
+func (e UnixEpoch) MarshalJSON() ([]byte, error) {
+	if e.IsZero() {
+		return []byte("null"), nil
+	}
+	return strconv.AppendInt(nil, e.UnixNano()/int64(time.Millisecond), 10), nil
+}
+
+func (g Geometry) coordinate(i int) float64 {
+	if i < len(g.Coordinates) {
+		return g.Coordinates[i]
+	}
+	return math.NaN()
+}
+
+// longitude in decimal degrees, NaN if missing
+func (g Geometry) Longitude() float64 { return g.coordinate(0) }
+
+// latitude in decimal degrees, NaN if missing
+func (g Geometry) Latitude() float64 { return g.coordinate(1) }
+
+// depth in kilometers, NaN if missing
+func (g Geometry) Depth() float64 { return g.coordinate(2) }
+
+func (f *Feature) Longitude() float64 { return f.Geometry.Longitude() }
+func (f *Feature) Latitude() float64  { return f.Geometry.Latitude() }
+func (f *Feature) Depth() float64     { return f.Geometry.Depth() }
+
+// origin time of the event
+func (f *Feature) Time() time.Time { return f.Properties.Time.Time }
+
 type transportFunc func(req *http.Request) (*http.Response, error)
 
 func (t transportFunc) RoundTrip(req *http.Request) (*http.Response, error) {
 	return t(req)
 }
 
-func NewClient() *Client {
-	return &Client{
-		c: &http.Client{
-			Transport: transportFunc(func(req *http.Request) (*http.Response, error) {
+const (
+	DefaultBaseURL   = "https://earthquake.usgs.gov/fdsnws/event/1"
+	DefaultUserAgent = "github.com/jasonmoo/usgs/earthquake v1.0"
+)
 
-				// https://earthquake.usgs.gov/fdsnws/event/1/[METHOD[?PARAMETERS]]
-				req.URL.Scheme = "https"
-				req.URL.Host = "earthquake.usgs.gov"
-				req.URL.Path = path.Join("/fdsnws/event/1", req.URL.Path)
+// use u as the service root instead of DefaultBaseURL, ie: a staging mirror, a proxy
+// or an httptest server.  Any FDSN event endpoint may be used.
+func WithBaseURL(u *url.URL) Option {
+	return func(c *Client) {
+		c.baseURL = u
+	}
+}
 
-				req.Header.Set("User-Agent", "github.com/jasonmoo/usgs/earthquake v1.0")
+// use rt to make the underlying http requests instead of http.DefaultTransport.
+func WithTransport(rt http.RoundTripper) Option {
+	return func(c *Client) {
+		c.transport = rt
+	}
+}
 
-				resp, err := http.DefaultTransport.RoundTrip(req)
-				if err != nil {
-					return nil, err
-				}
-				if resp.StatusCode != 200 {
-					var body []byte
-					if !strings.Contains(resp.Header.Get("Content-Type"), "html") {
-						body, _ = ioutil.ReadAll(resp.Body)
+// limit the time taken by each request, including reading the response body.
+// With WithRetry the limit covers every attempt and the waits between them.
+func WithTimeout(d time.Duration) Option {
+	return func(c *Client) {
+		c.timeout = d
+	}
+}
+
+// send ua as the User-Agent header instead of DefaultUserAgent.
+func WithUserAgent(ua string) Option {
+	return func(c *Client) {
+		c.userAgent = ua
+	}
+}
+
+func NewClient(opts ...Option) *Client {
+
+	base, _ := url.Parse(DefaultBaseURL)
+	feed, _ := url.Parse(DefaultFeedURL)
+
+	c := &Client{
+		baseURL:   base,
+		feedURL:   feed,
+		transport: http.DefaultTransport,
+		userAgent: DefaultUserAgent,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	c.c = &http.Client{
+		Timeout: c.timeout,
+		Transport: transportFunc(func(req *http.Request) (*http.Response, error) {
+
+			// https://earthquake.usgs.gov/fdsnws/event/1/[METHOD[?PARAMETERS]]
+			// relative requests are resolved against the base url, absolute ones are left alone
+			endpoint := req.URL.Path
+			if req.URL.Host == "" {
+				req.URL.Scheme = c.baseURL.Scheme
+				req.URL.Host = c.baseURL.Host
+				req.URL.User = c.baseURL.User
+				req.URL.Path = path.Join("/", c.baseURL.Path, req.URL.Path)
+			} else {
+				endpoint = req.URL.Scheme + "://" + req.URL.Host + req.URL.Path
+			}
+
+			req.Header.Set("User-Agent", c.userAgent)
+
+			resp, err := c.cachedRoundTrip(req, endpoint, func(req *http.Request) (*http.Response, error) {
+				return c.retry.roundTrip(req, func(req *http.Request) (*http.Response, error) {
+					if c.limiter != nil {
+						if err := c.limiter.Wait(req.Context()); err != nil {
+							return nil, err
+						}
 					}
-					resp.Body.Close()
-					return nil, fmt.Errorf("%s (%d): %q", resp.Status, resp.StatusCode, string(body))
+					return c.transport.RoundTrip(req)
+				})
+			})
+			if err != nil {
+				return nil, err
+			}
+			if resp.StatusCode != 200 {
+				var body []byte
+				if !strings.Contains(resp.Header.Get("Content-Type"), "html") {
+					body, _ = ioutil.ReadAll(resp.Body)
 				}
+				resp.Body.Close()
+				return nil, &APIError{
+					StatusCode: resp.StatusCode,
+					Status:     resp.Status,
+					Body:       string(body),
+					URL:        req.URL.String(),
+				}
+			}
 
-				return resp, nil
+			return resp, nil
 
-			}),
-		},
+		}),
+	}
+
+	return c
+
+}
+
+func (c *Client) get(ctx context.Context, path string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
 	}
+	return c.c.Do(req)
 }
 
 // request known enumerated parameter values for the interface.
 func (c *Client) GetApplicationInfo() (*GetApplicationInfoResponse, error) {
+	return c.GetApplicationInfoContext(context.Background())
+}
+
+func (c *Client) GetApplicationInfoContext(ctx context.Context) (*GetApplicationInfoResponse, error) {
 	// https://earthquake.usgs.gov/fdsnws/event/1/application.json
-	resp, err := c.c.Get("/application.json")
+	resp, err := c.get(ctx, "/application.json")
 	if err != nil {
 		return nil, err
 	}
@@ -200,8 +355,12 @@ func (c *Client) GetApplicationInfo() (*GetApplicationInfoResponse, error) {
 
 // request WADL for the interface.
 func (c *Client) GetApplicationWADL() (*GetApplicationWADLResponse, error) {
+	return c.GetApplicationWADLContext(context.Background())
+}
+
+func (c *Client) GetApplicationWADLContext(ctx context.Context) (*GetApplicationWADLResponse, error) {
 	// https://earthquake.usgs.gov/fdsnws/event/1/application.wadl
-	resp, err := c.c.Get("/application.wadl")
+	resp, err := c.get(ctx, "/application.wadl")
 	if err != nil {
 		return nil, err
 	}
@@ -215,8 +374,12 @@ func (c *Client) GetApplicationWADL() (*GetApplicationWADLResponse, error) {
 
 // request available catalogs.
 func (c *Client) GetCatalogs() (*GetCatalogsResponse, error) {
+	return c.GetCatalogsContext(context.Background())
+}
+
+func (c *Client) GetCatalogsContext(ctx context.Context) (*GetCatalogsResponse, error) {
 	// https://earthquake.usgs.gov/fdsnws/event/1/catalogs
-	resp, err := c.c.Get("/catalogs")
+	resp, err := c.get(ctx, "/catalogs")
 	if err != nil {
 		return nil, err
 	}
@@ -230,8 +393,12 @@ func (c *Client) GetCatalogs() (*GetCatalogsResponse, error) {
 
 // request available contributors
 func (c *Client) GetContributors() (*GetContributorsResponse, error) {
+	return c.GetContributorsContext(context.Background())
+}
+
+func (c *Client) GetContributorsContext(ctx context.Context) (*GetContributorsResponse, error) {
 	// https://earthquake.usgs.gov/fdsnws/event/1/contributors
-	resp, err := c.c.Get("/contributors")
+	resp, err := c.get(ctx, "/contributors")
 	if err != nil {
 		return nil, err
 	}
@@ -245,9 +412,22 @@ func (c *Client) GetContributors() (*GetContributorsResponse, error) {
 
 // to perform a count on a data request. Count uses the same parameters as the query method, and is availablein these formats: plain text (default), geojson, and xml.
 func (c *Client) GetCount(qp *queryParameters) (*GetCountResponse, error) {
+	return c.GetCountContext(context.Background(), qp)
+}
+
+func (c *Client) GetCountContext(ctx context.Context, qp *queryParameters) (*GetCountResponse, error) {
 	// https://earthquake.usgs.gov/fdsnws/event/1/count?format=geojson
 	// https://earthquake.usgs.gov/fdsnws/event/1/count?starttime=2014-01-01&endtime=2014-01-02
-	resp, err := c.c.Get("/count?" + qp.Encode())
+	// count results are always requested as geojson
+	cqp := *qp
+	cqp.Format = FormatGeoJSON
+	if err := cqp.Validate(); err != nil {
+		return nil, err
+	}
+	resp, err := c.get(ctx, "/count?"+cqp.Encode())
+	if qp.isNoData(err) {
+		return &GetCountResponse{MaxAllowed: maxLimit}, nil
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -261,13 +441,31 @@ func (c *Client) GetCount(qp *queryParameters) (*GetCountResponse, error) {
 
 // to submit a data request. See the parameters section for supported url parameters.
 func (c *Client) GetQuery(qp *queryParameters) (*GetQueryResponse, error) {
+	return c.GetQueryContext(context.Background(), qp)
+}
+
+func (c *Client) GetQueryContext(ctx context.Context, qp *queryParameters) (*GetQueryResponse, error) {
 	// https://earthquake.usgs.gov/fdsnws/event/1/query?format=geojson&starttime=2014-01-01&endtime=2014-01-02
 	// https://earthquake.usgs.gov/fdsnws/event/1/query?format=xml&starttime=2014-01-01&endtime=2014-01-02&minmagnitude=5
-	resp, err := c.c.Get("/query?" + qp.Encode())
+	if err := qp.Validate(); err != nil {
+		return nil, err
+	}
+	switch qp.Format {
+	case FormatGeoJSON, FormatCSV:
+	default:
+		return nil, fmt.Errorf("unsupported query format %q", qp.Format)
+	}
+	resp, err := c.get(ctx, "/query?"+qp.Encode())
+	if qp.isNoData(err) {
+		return &GetQueryResponse{Type: "FeatureCollection", Features: []Feature{}}, nil
+	}
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
+	if qp.Format == FormatCSV {
+		return decodeCSV(resp.Body)
+	}
 	var v GetQueryResponse
 	if err := json.NewDecoder(resp.Body).Decode(&v); err != nil {
 		return nil, err
@@ -277,11 +475,17 @@ func (c *Client) GetQuery(qp *queryParameters) (*GetQueryResponse, error) {
 
 // run a query and retrieve the full dataset over multiple requests
 func (c *Client) GetQueryPaged(qp *queryParameters, f func(*GetQueryResponse) error) error {
+	return c.GetQueryPagedContext(context.Background(), qp, f)
+}
+
+// run a query and retrieve the full dataset over multiple requests, stopping
+// as soon as ctx is done.
+func (c *Client) GetQueryPagedContext(ctx context.Context, qp *queryParameters, f func(*GetQueryResponse) error) error {
 
 	limit := qp.Limit
 	qp.Limit = 0
 
-	cresp, err := c.GetCount(qp)
+	cresp, err := c.GetCountContext(ctx, qp)
 	if err != nil {
 		return err
 	}
@@ -296,22 +500,80 @@ func (c *Client) GetQueryPaged(qp *queryParameters, f func(*GetQueryResponse) er
 		qp.TotalResults = cresp.Count
 	}
 
+	if qp.Concurrency > 1 {
+		return c.getQueryPagedConcurrent(ctx, qp, f)
+	}
+
 	for qp.Offset = 1; qp.Offset <= qp.TotalResults; qp.Offset += qp.Limit {
-		if err := func() error {
-			resp, err := c.c.Get("/query?" + qp.Encode())
-			if err != nil {
-				return err
-			}
-			defer resp.Body.Close()
-			var v GetQueryResponse
-			if err := json.NewDecoder(resp.Body).Decode(&v); err != nil {
-				return err
-			}
-			if err := f(&v); err != nil {
-				return err
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		v, err := c.GetQueryContext(ctx, qp)
+		if err != nil {
+			return err
+		}
+		if err := f(v); err != nil {
+			return err
+		}
+	}
+
+	return nil
+
+}
+
+// fetch up to qp.Concurrency pages at once, delivering them to f in offset order.
+// Workers only run ahead of f by qp.Concurrency pages, and any error cancels
+// all requests in flight.
+func (c *Client) getQueryPagedConcurrent(ctx context.Context, qp *queryParameters, f func(*GetQueryResponse) error) error {
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type page struct {
+		v   *GetQueryResponse
+		err error
+	}
+
+	var offsets []int
+	for offset := 1; offset <= qp.TotalResults; offset += qp.Limit {
+		offsets = append(offsets, offset)
+	}
+
+	pages := make([]chan page, len(offsets))
+	for i := range pages {
+		pages[i] = make(chan page, 1)
+	}
+
+	sem := make(chan struct{}, qp.Concurrency)
+
+	go func() {
+		for i, offset := range offsets {
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				return
 			}
-			return nil
-		}(); err != nil {
+			pqp := *qp
+			pqp.Offset = offset
+			go func(ch chan<- page) {
+				v, err := c.GetQueryContext(ctx, &pqp)
+				ch <- page{v, err}
+			}(pages[i])
+		}
+	}()
+
+	for _, ch := range pages {
+		var p page
+		select {
+		case p = <-ch:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		<-sem
+		if p.err != nil {
+			return p.err
+		}
+		if err := f(p.v); err != nil {
 			return err
 		}
 	}
@@ -322,8 +584,12 @@ func (c *Client) GetQueryPaged(qp *queryParameters, f func(*GetQueryResponse) er
 
 // request full service version number
 func (c *Client) GetVersion() (*GetVersionResponse, error) {
+	return c.GetVersionContext(context.Background())
+}
+
+func (c *Client) GetVersionContext(ctx context.Context) (*GetVersionResponse, error) {
 	// https://earthquake.usgs.gov/fdsnws/event/1/version
-	resp, err := c.c.Get("/version")
+	resp, err := c.get(ctx, "/version")
 	if err != nil {
 		return nil, err
 	}
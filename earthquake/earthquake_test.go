@@ -1,13 +1,78 @@
 package earthquake
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync"
 	"testing"
 	"time"
 )
 
 var client = NewClient()
 
+// newTestClient returns a client pointed at an httptest server running h.
+func newTestClient(t *testing.T, h http.HandlerFunc, opts ...Option) *Client {
+	t.Helper()
+	ts := httptest.NewServer(h)
+	t.Cleanup(ts.Close)
+	u, err := url.Parse(ts.URL + "/fdsnws/event/1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewClient(append([]Option{WithBaseURL(u)}, opts...)...)
+}
+
+func TestClientOptions(t *testing.T) {
+
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/fdsnws/event/1/version" {
+			t.Errorf("expected %q, got %q", "/fdsnws/event/1/version", r.URL.Path)
+		}
+		if ua := r.UserAgent(); ua != "test-agent" {
+			t.Errorf("expected %q, got %q", "test-agent", ua)
+		}
+		w.Write([]byte("1.2.3\n"))
+	}, WithUserAgent("test-agent"), WithTimeout(time.Second))
+
+	resp, err := c.GetVersion()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Version != "1.2.3" {
+		t.Errorf("expected %q, got %q", "1.2.3", resp.Version)
+	}
+
+}
+
+func TestClientWithTransport(t *testing.T) {
+
+	var called bool
+	c := NewClient(WithTransport(transportFunc(func(req *http.Request) (*http.Response, error) {
+		called = true
+		if req.URL.String() != DefaultBaseURL+"/version" {
+			t.Errorf("expected %q, got %q", DefaultBaseURL+"/version", req.URL.String())
+		}
+		rec := httptest.NewRecorder()
+		rec.WriteString("1.0")
+		return rec.Result(), nil
+	})))
+
+	if _, err := c.GetVersion(); err != nil {
+		t.Fatal(err)
+	}
+	if !called {
+		t.Error("expected transport to be called")
+	}
+
+}
+
 func TestGetApplicationInfo(t *testing.T) {
 
 	resp, err := client.GetApplicationInfo()
@@ -167,3 +232,229 @@ func TestGetVersion(t *testing.T) {
 		t.Errorf("expected version, got none")
 	}
 }
+
+func TestGetQueryPagedContextCancel(t *testing.T) {
+
+	var queries int
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/fdsnws/event/1/count":
+			w.Write([]byte(`{"count":3,"maxAllowed":20000}`))
+		case "/fdsnws/event/1/query":
+			queries++
+			w.Write([]byte(`{"type":"FeatureCollection","features":[{"type":"Feature","id":"a"}]}`))
+		}
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	qp := NewQueryParameters()
+	qp.Limit = 1
+	qp.TotalResults = 3
+
+	err := c.GetQueryPagedContext(ctx, qp, func(resp *GetQueryResponse) error {
+		cancel()
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected %v, got %v", context.Canceled, err)
+	}
+	if queries != 1 {
+		t.Errorf("expected 1 query, got %d", queries)
+	}
+
+}
+
+const testFeature = `{"type":"Feature","properties":{"mag":1.1,"place":"18km SE of Mammoth Lakes, CA","time":1514862245390,"updated":1514862476610,"tz":-480,"url":"https://earthquake.usgs.gov/earthquakes/eventpage/nc72947001","detail":"https://earthquake.usgs.gov/fdsnws/event/1/query?eventid=nc72947001&format=geojson","felt":null,"cdi":null,"mmi":null,"alert":null,"status":"automatic","tsunami":0,"sig":19,"net":"nc","code":"72947001","ids":",nc72947001,","sources":",nc,","types":",geoserve,nearby-cities,origin,phase-data,","nst":8,"dmin":0.0295,"rms":0.02,"gap":118,"magType":"md","type":"earthquake","title":"M 1.1 - 18km SE of Mammoth Lakes, CA"},"geometry":{"type":"Point","coordinates":[-118.8198333,37.4895,2.37]},"id":"nc72947001"}`
+
+func TestFeatureAccessors(t *testing.T) {
+
+	var f Feature
+	if err := json.Unmarshal([]byte(testFeature), &f); err != nil {
+		t.Fatal(err)
+	}
+
+	if f.Longitude() != -118.8198333 {
+		t.Errorf("expected %v, got %v", -118.8198333, f.Longitude())
+	}
+	if f.Latitude() != 37.4895 {
+		t.Errorf("expected %v, got %v", 37.4895, f.Latitude())
+	}
+	if f.Depth() != 2.37 {
+		t.Errorf("expected %v, got %v", 2.37, f.Depth())
+	}
+	expected := time.Date(2018, 1, 2, 3, 4, 5, 390e6, time.UTC)
+	if !f.Time().Equal(expected) {
+		t.Errorf("expected %v, got %v", expected, f.Time())
+	}
+
+	var empty Feature
+	if !math.IsNaN(empty.Depth()) {
+		t.Errorf("expected NaN, got %v", empty.Depth())
+	}
+
+}
+
+func TestPropertiesNullable(t *testing.T) {
+
+	var f Feature
+	if err := json.Unmarshal([]byte(testFeature), &f); err != nil {
+		t.Fatal(err)
+	}
+	p := f.Properties
+	if p.Alert != nil || p.Cdi != nil || p.Felt != nil || p.Mmi != nil {
+		t.Errorf("expected nil alert, cdi, felt and mmi, got %v %v %v %v", p.Alert, p.Cdi, p.Felt, p.Mmi)
+	}
+	if p.Gap == nil || *p.Gap != 118 {
+		t.Errorf("expected gap 118, got %v", p.Gap)
+	}
+	if p.Nst == nil || *p.Nst != 8 {
+		t.Errorf("expected nst 8, got %v", p.Nst)
+	}
+
+	const data = `{"alert":"yellow","felt":0,"gap":null,"time":null}`
+	if err := json.Unmarshal([]byte(data), &p); err != nil {
+		t.Fatal(err)
+	}
+	if p.Alert == nil || *p.Alert != AlertLevelYellow {
+		t.Errorf("expected %q, got %v", AlertLevelYellow, p.Alert)
+	}
+	if p.Felt == nil || *p.Felt != 0 {
+		t.Errorf("expected felt 0, got %v", p.Felt)
+	}
+	if p.Gap != nil {
+		t.Errorf("expected nil gap, got %v", *p.Gap)
+	}
+	if !p.Time.IsZero() {
+		t.Errorf("expected zero time, got %v", p.Time)
+	}
+
+	out, err := json.Marshal(f.Properties.Time)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != "1514862245390" {
+		t.Errorf("expected %q, got %q", "1514862245390", out)
+	}
+
+}
+
+func TestGetQueryPagedConcurrent(t *testing.T) {
+
+	const total = 20
+
+	var (
+		mu       sync.Mutex
+		inflight int
+		peak     int
+	)
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/fdsnws/event/1/count":
+			fmt.Fprintf(w, `{"count":%d,"maxAllowed":20000}`, total)
+		case "/fdsnws/event/1/query":
+			mu.Lock()
+			inflight++
+			if inflight > peak {
+				peak = inflight
+			}
+			mu.Unlock()
+
+			offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+			// later pages finish first
+			time.Sleep(time.Duration(total-offset) * time.Millisecond)
+			fmt.Fprintf(w, `{"features":[{"id":"%d"}]}`, offset)
+
+			mu.Lock()
+			inflight--
+			mu.Unlock()
+		}
+	})
+
+	qp := NewQueryParameters()
+	qp.Limit = 1
+	qp.TotalResults = total
+	qp.Concurrency = 4
+
+	var next = 1
+	err := c.GetQueryPaged(qp, func(resp *GetQueryResponse) error {
+		if id := strconv.Itoa(next); resp.Features[0].ID != id {
+			t.Errorf("expected %q, got %q", id, resp.Features[0].ID)
+		}
+		next++
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next != total+1 {
+		t.Errorf("expected %d pages, got %d", total, next-1)
+	}
+	if peak > qp.Concurrency {
+		t.Errorf("expected at most %d concurrent requests, got %d", qp.Concurrency, peak)
+	}
+
+	// a callback error cancels the requests still in flight
+	var started, cancelled int
+	c = newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/fdsnws/event/1/count":
+			fmt.Fprintf(w, `{"count":%d,"maxAllowed":20000}`, total)
+		case "/fdsnws/event/1/query":
+			offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+			if offset == 1 {
+				// answer once the other pages are in flight
+				for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+					mu.Lock()
+					s := started
+					mu.Unlock()
+					if s == qp.Concurrency-1 {
+						break
+					}
+				}
+				fmt.Fprint(w, `{"features":[{"id":"1"}]}`)
+				return
+			}
+			mu.Lock()
+			started++
+			mu.Unlock()
+			select {
+			case <-r.Context().Done():
+				mu.Lock()
+				cancelled++
+				mu.Unlock()
+			case <-time.After(5 * time.Second):
+				t.Errorf("offset %d: request not cancelled", offset)
+			}
+		}
+	})
+
+	stop := errors.New("stop")
+	var calls int
+	err = c.GetQueryPaged(qp, func(resp *GetQueryResponse) error {
+		calls++
+		return stop
+	})
+	if err != stop {
+		t.Errorf("expected %v, got %v", stop, err)
+	}
+	if calls != 1 {
+		t.Errorf("expected 1 call, got %d", calls)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		mu.Lock()
+		s, n := started, cancelled
+		mu.Unlock()
+		if s > 0 && s == n {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected in flight requests to be cancelled, %d of %d were", n, s)
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+}
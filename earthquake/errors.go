@@ -0,0 +1,86 @@
+package earthquake
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// APIError is returned for any non-200 response from the service.  Callers
+// can inspect it with errors.As or the Is* helpers.
+//
+// https://earthquake.usgs.gov/fdsnws/event/1/#errors
+// 204 No Content        no data matched the request (or 404 with nodata=404)
+// 400 Bad Request       invalid or unsupported parameters
+// 409 Conflict          the requested event has been deleted
+// 413 Request Too Large the request would exceed the service limit
+// 503 Service Unavailable the service is overloaded
+type APIError struct {
+	StatusCode int
+	Status     string
+	Body       string
+	URL        string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%s (%d): %q", e.Status, e.StatusCode, e.Body)
+}
+
+func statusCode(err error) int {
+	var e *APIError
+	if errors.As(err, &e) {
+		return e.StatusCode
+	}
+	return 0
+}
+
+// reports whether err is the service's response to a request matching no data,
+// a 204 or a 404 to a request made with nodata=404.  Any other 404 is not.
+// The query methods return empty results rather than this error.
+func IsNoData(err error) bool {
+	var e *APIError
+	if !errors.As(err, &e) {
+		return false
+	}
+	switch e.StatusCode {
+	case http.StatusNoContent:
+		return true
+	case http.StatusNotFound:
+		u, err := url.Parse(e.URL)
+		return err == nil && u.Query().Get("nodata") == "404"
+	}
+	return false
+}
+
+// reports whether err is the service rejecting the request parameters.
+func IsBadRequest(err error) bool {
+	return statusCode(err) == http.StatusBadRequest
+}
+
+// reports whether err is the service's response to a request for a deleted event.
+func IsDeleted(err error) bool {
+	return statusCode(err) == http.StatusConflict
+}
+
+// reports whether err is the service refusing the request due to load.
+func IsRateLimited(err error) bool {
+	code := statusCode(err)
+	return code == http.StatusTooManyRequests || code == http.StatusServiceUnavailable
+}
+
+// ValidationErrors lists every rule violated by a queryParameters.
+type ValidationErrors []error
+
+func (ve ValidationErrors) Error() string {
+	msgs := make([]string, len(ve))
+	for i, err := range ve {
+		msgs[i] = err.Error()
+	}
+	return "invalid query parameters: " + strings.Join(msgs, "; ")
+}
+
+func (ve ValidationErrors) Unwrap() []error {
+	return ve
+}
@@ -0,0 +1,127 @@
+package earthquake
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestAPIError(t *testing.T) {
+
+	for _, tc := range []struct {
+		code                                   int
+		noData, badRequest, deleted, rateLimit bool
+	}{
+		{code: http.StatusNoContent, noData: true},
+		{code: http.StatusNotFound},
+		{code: http.StatusBadRequest, badRequest: true},
+		{code: http.StatusConflict, deleted: true},
+		{code: http.StatusTooManyRequests, rateLimit: true},
+		{code: http.StatusServiceUnavailable, rateLimit: true},
+	} {
+		c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(tc.code)
+			if tc.code != http.StatusNoContent {
+				w.Write([]byte("error body"))
+			}
+		})
+
+		_, err := c.GetVersion()
+
+		var e *APIError
+		if !errors.As(err, &e) {
+			t.Fatalf("expected *APIError, got %T", err)
+		}
+		if e.StatusCode != tc.code {
+			t.Errorf("expected %d, got %d", tc.code, e.StatusCode)
+		}
+		if tc.code != http.StatusNoContent && e.Body != "error body" {
+			t.Errorf("expected %q, got %q", "error body", e.Body)
+		}
+		if e.URL == "" {
+			t.Error("expected url, got none")
+		}
+		if IsNoData(err) != tc.noData || IsBadRequest(err) != tc.badRequest || IsDeleted(err) != tc.deleted || IsRateLimited(err) != tc.rateLimit {
+			t.Errorf("%d: unexpected classification", tc.code)
+		}
+	}
+
+}
+
+func TestNoDataEmptyResults(t *testing.T) {
+
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("nodata") == "404" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	for _, nodata := range []int{0, 404} {
+
+		qp := NewQueryParameters()
+		qp.NoData = nodata
+
+		resp, err := c.GetQuery(qp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.Features == nil || len(resp.Features) != 0 {
+			t.Errorf("expected empty features, got %v", resp.Features)
+		}
+
+		cresp, err := c.GetCount(qp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cresp.Count != 0 {
+			t.Errorf("expected 0, got %d", cresp.Count)
+		}
+
+		var pages int
+		qp.TotalResults = 100
+		if err := c.GetQueryPaged(qp, func(*GetQueryResponse) error { pages++; return nil }); err != nil {
+			t.Fatal(err)
+		}
+		if pages != 0 {
+			t.Errorf("expected no pages, got %d", pages)
+		}
+
+		it, err := c.GetQueryStream(qp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if it.Next() || it.Err() != nil {
+			t.Errorf("expected empty iterator, got %v", it.Err())
+		}
+		it.Close()
+
+		xresp, err := c.GetQueryQuakeML(qp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(xresp.EventParameters.Events) != 0 {
+			t.Errorf("expected no events, got %d", len(xresp.EventParameters.Events))
+		}
+
+		var buf bytes.Buffer
+		if err := c.GetQueryKML(qp, &buf); err != nil {
+			t.Fatal(err)
+		}
+		if buf.Len() != 0 {
+			t.Errorf("expected no kml, got %q", buf.String())
+		}
+
+	}
+
+	// a 404 without nodata=404 is still an error
+	c = newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	if _, err := c.GetQuery(NewQueryParameters()); statusCode(err) != http.StatusNotFound || IsNoData(err) {
+		t.Errorf("expected 404 error, got %v", err)
+	}
+
+}
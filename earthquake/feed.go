@@ -0,0 +1,119 @@
+package earthquake
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"path"
+	"sync"
+)
+
+// https://earthquake.usgs.gov/earthquakes/feed/v1.0/geojson.php
+const DefaultFeedURL = "https://earthquake.usgs.gov/earthquakes/feed/v1.0/summary"
+
+type (
+	FeedMagnitude string
+	FeedPeriod    string
+
+	// Feed polls one of the pre-built summary feeds, only decoding it when it
+	// has been modified since the last poll.
+	Feed struct {
+		Magnitude FeedMagnitude
+		Period    FeedPeriod
+
+		c *Client
+
+		mu           sync.Mutex
+		lastModified string
+	}
+)
+
+const (
+	FeedMagnitudeSignificant FeedMagnitude = "significant"
+	FeedMagnitude4_5         FeedMagnitude = "4.5"
+	FeedMagnitude2_5         FeedMagnitude = "2.5"
+	FeedMagnitude1_0         FeedMagnitude = "1.0"
+	FeedMagnitudeAll         FeedMagnitude = "all"
+
+	FeedPeriodHour  FeedPeriod = "hour"
+	FeedPeriodDay   FeedPeriod = "day"
+	FeedPeriodWeek  FeedPeriod = "week"
+	FeedPeriodMonth FeedPeriod = "month"
+)
+
+// use u as the root of the summary feeds instead of DefaultFeedURL.
+func WithFeedURL(u *url.URL) Option {
+	return func(c *Client) {
+		c.feedURL = u
+	}
+}
+
+func (c *Client) feedURLFor(mag FeedMagnitude, period FeedPeriod) string {
+	u := *c.feedURL
+	u.Path = path.Join("/", u.Path, string(mag)+"_"+string(period)+".geojson")
+	return u.String()
+}
+
+// request a summary feed, ie: all M4.5+ events of the past day.
+func (c *Client) GetFeed(mag FeedMagnitude, period FeedPeriod) (*GetQueryResponse, error) {
+	return c.GetFeedContext(context.Background(), mag, period)
+}
+
+func (c *Client) GetFeedContext(ctx context.Context, mag FeedMagnitude, period FeedPeriod) (*GetQueryResponse, error) {
+	// https://earthquake.usgs.gov/earthquakes/feed/v1.0/summary/4.5_day.geojson
+	v, _, err := c.NewFeed(mag, period).Poll(ctx)
+	return v, err
+}
+
+func (c *Client) NewFeed(mag FeedMagnitude, period FeedPeriod) *Feed {
+	return &Feed{
+		Magnitude: mag,
+		Period:    period,
+		c:         c,
+	}
+}
+
+// the url of the feed
+func (f *Feed) URL() string {
+	return f.c.feedURLFor(f.Magnitude, f.Period)
+}
+
+// request the feed with If-Modified-Since set to the Last-Modified of the
+// previous poll.  Returns a nil response and false when it is unchanged.
+func (f *Feed) Poll(ctx context.Context) (*GetQueryResponse, bool, error) {
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, f.URL(), nil)
+	if err != nil {
+		return nil, false, err
+	}
+
+	f.mu.Lock()
+	if f.lastModified != "" {
+		req.Header.Set("If-Modified-Since", f.lastModified)
+	}
+	f.mu.Unlock()
+
+	resp, err := f.c.c.Do(req)
+	if statusCode(err) == http.StatusNotModified {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	defer resp.Body.Close()
+
+	var v GetQueryResponse
+	if err := json.NewDecoder(resp.Body).Decode(&v); err != nil {
+		return nil, false, err
+	}
+
+	if lm := resp.Header.Get("Last-Modified"); lm != "" {
+		f.mu.Lock()
+		f.lastModified = lm
+		f.mu.Unlock()
+	}
+
+	return &v, true, nil
+
+}
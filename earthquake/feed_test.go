@@ -0,0 +1,63 @@
+package earthquake
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestFeed(t *testing.T) {
+
+	const lastModified = "Tue, 02 Jan 2018 04:00:00 GMT"
+
+	var requests int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path != "/earthquakes/feed/v1.0/summary/4.5_day.geojson" {
+			t.Errorf("unexpected path %q", r.URL.Path)
+		}
+		if r.Header.Get("If-Modified-Since") == lastModified {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("Last-Modified", lastModified)
+		w.Write([]byte(`{"type":"FeatureCollection","metadata":{"title":"USGS Magnitude 4.5+ Earthquakes, Past Day","count":1},"features":[` + testFeature + `]}`))
+	}))
+	defer ts.Close()
+
+	u, _ := url.Parse(ts.URL + "/earthquakes/feed/v1.0/summary")
+	c := NewClient(WithFeedURL(u))
+
+	resp, err := c.GetFeed(FeedMagnitude4_5, FeedPeriodDay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Features) != 1 || resp.Features[0].ID != "nc72947001" {
+		t.Errorf("unexpected features %+v", resp.Features)
+	}
+
+	f := c.NewFeed(FeedMagnitude4_5, FeedPeriodDay)
+
+	resp, modified, err := f.Poll(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !modified || resp == nil || resp.Metadata.Count != 1 {
+		t.Errorf("expected modified response, got %v %+v", modified, resp)
+	}
+
+	resp, modified, err = f.Poll(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if modified || resp != nil {
+		t.Errorf("expected unmodified, got %v %+v", modified, resp)
+	}
+
+	if requests != 3 {
+		t.Errorf("expected 3 requests, got %d", requests)
+	}
+
+}
@@ -0,0 +1,32 @@
+package earthquake
+
+import (
+	"context"
+	"io"
+)
+
+// submit a data request and stream the KML response to w.  The format of qp is ignored,
+// use KMLColorBy and KMLAnimated to control the output.  Nothing is written to w for a
+// request matching no data.
+func (c *Client) GetQueryKML(qp *queryParameters, w io.Writer) error {
+	return c.GetQueryKMLContext(context.Background(), qp, w)
+}
+
+func (c *Client) GetQueryKMLContext(ctx context.Context, qp *queryParameters, w io.Writer) error {
+	// https://earthquake.usgs.gov/fdsnws/event/1/query?format=kml&kmlcolorby=depth&kmlanimated=true
+	kqp := *qp
+	kqp.Format = FormatKML
+	if err := kqp.Validate(); err != nil {
+		return err
+	}
+	resp, err := c.get(ctx, "/query?"+kqp.Encode())
+	if kqp.isNoData(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, err = io.Copy(w, resp.Body)
+	return err
+}
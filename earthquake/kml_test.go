@@ -0,0 +1,34 @@
+package earthquake
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+)
+
+func TestGetQueryKML(t *testing.T) {
+
+	const body = `<?xml version="1.0" encoding="UTF-8"?><kml xmlns="http://www.opengis.net/kml/2.2"><Document/></kml>`
+
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("format") != "kml" || q.Get("kmlcolorby") != "age" || q.Get("kmlanimated") != "true" {
+			t.Errorf("unexpected query %q", r.URL.RawQuery)
+		}
+		w.Header().Set("Content-Type", "application/vnd.google-earth.kml+xml")
+		w.Write([]byte(body))
+	})
+
+	qp := NewQueryParameters()
+	qp.KMLColorBy = KMLColorByAge
+	qp.KMLAnimated = true
+
+	var buf bytes.Buffer
+	if err := c.GetQueryKML(qp, &buf); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != body {
+		t.Errorf("expected %q, got %q", body, buf.String())
+	}
+
+}
@@ -0,0 +1,534 @@
+package earthquake
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+)
+
+type (
+	// angles in degrees following the Aki & Richards convention
+	NodalPlane struct {
+		Strike float64
+		Dip    float64
+		Rake   float64
+	}
+
+	// azimuth and plunge in degrees, length is the eigenvalue in Nm
+	Axis struct {
+		Azimuth float64
+		Plunge  float64
+		Length  float64
+	}
+
+	// moment tensor components in Nm, in Up-South-East coordinates
+	Tensor struct {
+		Mrr, Mtt, Mpp, Mrt, Mrp, Mtp float64
+	}
+
+	FocalMechanism struct {
+		Source      string
+		Code        string
+		NodalPlane1 NodalPlane
+		NodalPlane2 NodalPlane
+		// nil when not provided
+		TAxis, NAxis, PAxis *Axis
+	}
+
+	MomentTensor struct {
+		FocalMechanism
+		// nil when not provided
+		Tensor *Tensor
+		// Nm
+		ScalarMoment float64
+		// percent, 0 to 100
+		PercentDoubleCouple  float64
+		DerivedMagnitude     float64
+		DerivedMagnitudeType string
+		// km
+		DerivedDepth float64
+		// degrees
+		DerivedLatitude float64
+		// degrees
+		DerivedLongitude float64
+		// seconds
+		SourceTimeDuration float64
+	}
+
+	FaultType string
+)
+
+const (
+	FaultTypeNormal     FaultType = "normal"
+	FaultTypeReverse    FaultType = "reverse"
+	FaultTypeStrikeSlip FaultType = "strike-slip"
+)
+
+var ErrNoMechanism = errors.New("no focal mechanism")
+
+// classify slip by rake: within 45° of horizontal is strike-slip, otherwise
+// reverse when the hanging wall moves up and normal when it moves down.
+func (np NodalPlane) FaultType() FaultType {
+	rake := math.Mod(np.Rake, 360)
+	if rake > 180 {
+		rake -= 360
+	} else if rake <= -180 {
+		rake += 360
+	}
+	switch {
+	case rake > 45 && rake < 135:
+		return FaultTypeReverse
+	case rake < -45 && rake > -135:
+		return FaultTypeNormal
+	}
+	return FaultTypeStrikeSlip
+}
+
+func (fm *FocalMechanism) FaultType() FaultType {
+	return fm.NodalPlane1.FaultType()
+}
+
+// the auxiliary plane of a double couple source
+func (np NodalPlane) AuxiliaryPlane() NodalPlane {
+	n, s := np.vectors()
+	return planeFromVectors(s, n)
+}
+
+const rad = math.Pi / 180
+
+type vec3 [3]float64 // north, east, down
+
+func (v vec3) add(u vec3) vec3      { return vec3{v[0] + u[0], v[1] + u[1], v[2] + u[2]} }
+func (v vec3) sub(u vec3) vec3      { return vec3{v[0] - u[0], v[1] - u[1], v[2] - u[2]} }
+func (v vec3) scale(f float64) vec3 { return vec3{v[0] * f, v[1] * f, v[2] * f} }
+
+// fault normal and slip vectors of a plane
+func (np NodalPlane) vectors() (n, s vec3) {
+	phi, delta, lambda := np.Strike*rad, np.Dip*rad, np.Rake*rad
+	n = vec3{
+		-math.Sin(delta) * math.Sin(phi),
+		math.Sin(delta) * math.Cos(phi),
+		-math.Cos(delta),
+	}
+	s = vec3{
+		math.Cos(lambda)*math.Cos(phi) + math.Cos(delta)*math.Sin(lambda)*math.Sin(phi),
+		math.Cos(lambda)*math.Sin(phi) - math.Cos(delta)*math.Sin(lambda)*math.Cos(phi),
+		-math.Sin(lambda) * math.Sin(delta),
+	}
+	return n, s
+}
+
+func planeFromVectors(n, s vec3) NodalPlane {
+
+	// the normal points up out of the footwall
+	if n[2] > 0 {
+		n, s = n.scale(-1), s.scale(-1)
+	}
+
+	delta := math.Acos(math.Max(-1, math.Min(1, -n[2])))
+	var phi, lambda float64
+	if sd := math.Sin(delta); sd < 1e-9 {
+		// horizontal plane, take strike from the slip direction
+		phi = math.Atan2(s[1], s[0])
+	} else {
+		phi = math.Atan2(-n[0], n[1])
+		lambda = math.Atan2(-s[2]/sd, s[0]*math.Cos(phi)+s[1]*math.Sin(phi))
+	}
+
+	strike := math.Mod(phi/rad+360, 360)
+	return NodalPlane{Strike: strike, Dip: delta / rad, Rake: lambda / rad}
+
+}
+
+func axisFromVector(v vec3, length float64) Axis {
+	if v[2] < 0 {
+		v = v.scale(-1)
+	}
+	return Axis{
+		Azimuth: math.Mod(math.Atan2(v[1], v[0])/rad+360, 360),
+		Plunge:  math.Asin(math.Max(-1, math.Min(1, v[2]))) / rad,
+		Length:  length,
+	}
+}
+
+func (a Axis) vector() vec3 {
+	az, pl := a.Azimuth*rad, a.Plunge*rad
+	return vec3{math.Cos(pl) * math.Cos(az), math.Cos(pl) * math.Sin(az), math.Sin(pl)}
+}
+
+// the symmetric matrix in north-east-down coordinates
+func (t Tensor) ned() [3][3]float64 {
+	return [3][3]float64{
+		{t.Mtt, -t.Mtp, t.Mrt},
+		{-t.Mtp, t.Mpp, -t.Mrp},
+		{t.Mrt, -t.Mrp, t.Mrr},
+	}
+}
+
+// eigenvalues in ascending order with their eigenvectors, by Jacobi rotation
+func eigen(a [3][3]float64) (values [3]float64, vectors [3]vec3) {
+
+	v := [3][3]float64{{1, 0, 0}, {0, 1, 0}, {0, 0, 1}}
+
+	for sweep := 0; sweep < 50; sweep++ {
+		off := a[0][1]*a[0][1] + a[0][2]*a[0][2] + a[1][2]*a[1][2]
+		if off < 1e-30*(a[0][0]*a[0][0]+a[1][1]*a[1][1]+a[2][2]*a[2][2]) || off == 0 {
+			break
+		}
+		for p := 0; p < 2; p++ {
+			for q := p + 1; q < 3; q++ {
+				if a[p][q] == 0 {
+					continue
+				}
+				theta := (a[q][q] - a[p][p]) / (2 * a[p][q])
+				t := math.Copysign(1, theta) / (math.Abs(theta) + math.Sqrt(theta*theta+1))
+				c := 1 / math.Sqrt(t*t+1)
+				s := t * c
+				for k := 0; k < 3; k++ {
+					akp, akq := a[k][p], a[k][q]
+					a[k][p] = c*akp - s*akq
+					a[k][q] = s*akp + c*akq
+				}
+				for k := 0; k < 3; k++ {
+					apk, aqk := a[p][k], a[q][k]
+					a[p][k] = c*apk - s*aqk
+					a[q][k] = s*apk + c*aqk
+				}
+				for k := 0; k < 3; k++ {
+					vkp, vkq := v[k][p], v[k][q]
+					v[k][p] = c*vkp - s*vkq
+					v[k][q] = s*vkp + c*vkq
+				}
+			}
+		}
+	}
+
+	idx := [3]int{0, 1, 2}
+	for i := 0; i < 2; i++ {
+		for j := i + 1; j < 3; j++ {
+			if a[idx[j]][idx[j]] < a[idx[i]][idx[i]] {
+				idx[i], idx[j] = idx[j], idx[i]
+			}
+		}
+	}
+	for i, k := range idx {
+		values[i] = a[k][k]
+		vectors[i] = vec3{v[0][k], v[1][k], v[2][k]}
+	}
+
+	return values, vectors
+
+}
+
+// the tension, null and pressure axes of the tensor
+func (t Tensor) PrincipalAxes() (T, N, P Axis) {
+	values, vectors := eigen(t.ned())
+	return axisFromVector(vectors[2], values[2]), axisFromVector(vectors[1], values[1]), axisFromVector(vectors[0], values[0])
+}
+
+// the nodal planes of the best double couple of the tensor
+func (t Tensor) NodalPlanes() (NodalPlane, NodalPlane) {
+	T, _, P := t.PrincipalAxes()
+	return nodalPlanesFromAxes(T, P)
+}
+
+func nodalPlanesFromAxes(T, P Axis) (NodalPlane, NodalPlane) {
+	tv, pv := T.vector(), P.vector()
+	n := tv.add(pv).scale(1 / math.Sqrt2)
+	s := tv.sub(pv).scale(1 / math.Sqrt2)
+	return planeFromVectors(n, s), planeFromVectors(s, n)
+}
+
+// the scalar moment in Nm, as defined by Silver & Jordan (1982)
+func (t Tensor) ScalarMoment() float64 {
+	return math.Sqrt((t.Mrr*t.Mrr+t.Mtt*t.Mtt+t.Mpp*t.Mpp)/2 + t.Mrt*t.Mrt + t.Mrp*t.Mrp + t.Mtp*t.Mtp)
+}
+
+// the percentage of the deviatoric tensor that is double couple
+func (t Tensor) PercentDoubleCouple() float64 {
+	values, _ := eigen(t.ned())
+	iso := (values[0] + values[1] + values[2]) / 3
+	for i := range values {
+		values[i] -= iso
+	}
+	// order by absolute value
+	min, max := math.Abs(values[0]), math.Abs(values[0])
+	for _, v := range values[1:] {
+		min = math.Min(min, math.Abs(v))
+		max = math.Max(max, math.Abs(v))
+	}
+	if max == 0 {
+		return 0
+	}
+	return (1 - 2*min/max) * 100
+}
+
+// moment magnitude of a scalar moment in Nm, as defined by Hanks & Kanamori (1979)
+func MomentMagnitude(m0 float64) float64 {
+	return 2.0 / 3.0 * (math.Log10(m0) - 9.1)
+}
+
+// helpers reading the string properties of a product
+func propertyFloat(props map[string]string, key string) (float64, bool) {
+	v, err := strconv.ParseFloat(props[key], 64)
+	return v, err == nil
+}
+
+func propertyPlane(props map[string]string, prefix string) (NodalPlane, bool) {
+	strike, ok1 := propertyFloat(props, prefix+"-strike")
+	dip, ok2 := propertyFloat(props, prefix+"-dip")
+	rake, ok3 := propertyFloat(props, prefix+"-rake")
+	if !ok3 {
+		// older products name the rake slip
+		rake, ok3 = propertyFloat(props, prefix+"-slip")
+	}
+	return NodalPlane{Strike: strike, Dip: dip, Rake: rake}, ok1 && ok2 && ok3
+}
+
+func propertyAxis(props map[string]string, prefix string) *Axis {
+	az, ok1 := propertyFloat(props, prefix+"-azimuth")
+	pl, ok2 := propertyFloat(props, prefix+"-plunge")
+	if !ok1 || !ok2 {
+		return nil
+	}
+	length, _ := propertyFloat(props, prefix+"-length")
+	return &Axis{Azimuth: az, Plunge: pl, Length: length}
+}
+
+func propertyTensor(props map[string]string) *Tensor {
+	var (
+		t  Tensor
+		ok = true
+	)
+	for key, v := range map[string]*float64{
+		"tensor-mrr": &t.Mrr,
+		"tensor-mtt": &t.Mtt,
+		"tensor-mpp": &t.Mpp,
+		"tensor-mrt": &t.Mrt,
+		"tensor-mrp": &t.Mrp,
+		"tensor-mtp": &t.Mtp,
+	} {
+		var exists bool
+		*v, exists = propertyFloat(props, key)
+		ok = ok && exists
+	}
+	if !ok {
+		return nil
+	}
+	return &t
+}
+
+// fill in whatever planes and axes can be derived from the nodal planes, the
+// axes already set on fm and the tensor, any of which may be nil
+func (fm *FocalMechanism) complete(np1, np2 *NodalPlane, t *Tensor) error {
+
+	switch {
+	case np1 != nil && np2 != nil:
+		fm.NodalPlane1, fm.NodalPlane2 = *np1, *np2
+	case np1 != nil:
+		fm.NodalPlane1, fm.NodalPlane2 = *np1, np1.AuxiliaryPlane()
+	case np2 != nil:
+		fm.NodalPlane1, fm.NodalPlane2 = np2.AuxiliaryPlane(), *np2
+	case t != nil:
+		fm.NodalPlane1, fm.NodalPlane2 = t.NodalPlanes()
+	case fm.TAxis != nil && fm.PAxis != nil:
+		fm.NodalPlane1, fm.NodalPlane2 = nodalPlanesFromAxes(*fm.TAxis, *fm.PAxis)
+	default:
+		return ErrNoMechanism
+	}
+
+	if t != nil && (fm.TAxis == nil || fm.NAxis == nil || fm.PAxis == nil) {
+		T, N, P := t.PrincipalAxes()
+		fm.TAxis, fm.NAxis, fm.PAxis = &T, &N, &P
+	}
+
+	return nil
+
+}
+
+// complete fm from the properties of a product
+func (fm *FocalMechanism) completeProperties(props map[string]string, t *Tensor) error {
+	var np1, np2 *NodalPlane
+	if np, ok := propertyPlane(props, "nodal-plane-1"); ok {
+		np1 = &np
+	}
+	if np, ok := propertyPlane(props, "nodal-plane-2"); ok {
+		np2 = &np
+	}
+	fm.TAxis = propertyAxis(props, "t-axis")
+	fm.NAxis = propertyAxis(props, "n-axis")
+	fm.PAxis = propertyAxis(props, "p-axis")
+	return fm.complete(np1, np2, t)
+}
+
+// the focal mechanism described by a focal-mechanism or moment-tensor product
+func NewFocalMechanism(p *Product) (*FocalMechanism, error) {
+	fm := &FocalMechanism{Source: p.Source, Code: p.Code}
+	if err := fm.completeProperties(p.Properties, propertyTensor(p.Properties)); err != nil {
+		return nil, err
+	}
+	return fm, nil
+}
+
+// the moment tensor described by a moment-tensor product
+func NewMomentTensor(p *Product) (*MomentTensor, error) {
+
+	props := p.Properties
+	mt := &MomentTensor{
+		FocalMechanism:       FocalMechanism{Source: p.Source, Code: p.Code},
+		Tensor:               propertyTensor(props),
+		DerivedMagnitudeType: props["derived-magnitude-type"],
+	}
+	if err := mt.completeProperties(props, mt.Tensor); err != nil {
+		return nil, err
+	}
+
+	mt.ScalarMoment, _ = propertyFloat(props, "scalar-moment")
+	mt.DerivedMagnitude, _ = propertyFloat(props, "derived-magnitude")
+	mt.DerivedDepth, _ = propertyFloat(props, "derived-depth")
+	mt.DerivedLatitude, _ = propertyFloat(props, "derived-latitude")
+	mt.DerivedLongitude, _ = propertyFloat(props, "derived-longitude")
+	mt.SourceTimeDuration, _ = propertyFloat(props, "sourcetime-duration")
+
+	if mt.Tensor != nil && mt.ScalarMoment == 0 {
+		mt.ScalarMoment = mt.Tensor.ScalarMoment()
+	}
+	// percent-double-couple is given as a fraction by some sources.  Only values
+	// below 1 are read as a fraction, so 1 is 1% rather than a pure double couple.
+	if pdc, ok := propertyFloat(props, "percent-double-couple"); ok {
+		if pdc < 1 {
+			pdc *= 100
+		}
+		mt.PercentDoubleCouple = pdc
+	} else if mt.Tensor != nil {
+		mt.PercentDoubleCouple = mt.Tensor.PercentDoubleCouple()
+	}
+	if mt.DerivedMagnitude == 0 && mt.ScalarMoment > 0 {
+		mt.DerivedMagnitude = MomentMagnitude(mt.ScalarMoment)
+		mt.DerivedMagnitudeType = "Mw"
+	}
+
+	return mt, nil
+
+}
+
+// the preferred focal mechanism of the event, from its focal-mechanism product
+// or failing that its moment-tensor product.
+func (e *EventDetail) FocalMechanism() (*FocalMechanism, error) {
+	for _, t := range []ProductType{ProductTypeFocalMechanism, ProductTypeMomentTensor} {
+		if p := e.PreferredProduct(t); p != nil {
+			return NewFocalMechanism(p)
+		}
+	}
+	return nil, ErrNoMechanism
+}
+
+// the preferred moment tensor of the event
+func (e *EventDetail) MomentTensor() (*MomentTensor, error) {
+	p := e.PreferredProduct(ProductTypeMomentTensor)
+	if p == nil {
+		return nil, ErrNoMechanism
+	}
+	return NewMomentTensor(p)
+}
+
+func quakeMLPlane(np *QuakeMLNodalPlane) *NodalPlane {
+	if np == nil {
+		return nil
+	}
+	return &NodalPlane{Strike: np.Strike.Value, Dip: np.Dip.Value, Rake: np.Rake.Value}
+}
+
+func quakeMLAxis(a *QuakeMLAxis) *Axis {
+	if a == nil {
+		return nil
+	}
+	return &Axis{Azimuth: a.Azimuth.Value, Plunge: a.Plunge.Value, Length: a.Length.Value}
+}
+
+// the focal mechanism in typed form, deriving missing planes and axes the same
+// way as NewFocalMechanism
+func (q *QuakeMLFocalMechanism) FocalMechanism() (*FocalMechanism, error) {
+
+	fm := &FocalMechanism{Source: q.DataSource, Code: q.DataID}
+	if fm.Source == "" && q.CreationInfo != nil {
+		fm.Source = strings.ToLower(q.CreationInfo.AgencyID)
+	}
+
+	if q.PrincipalAxes != nil {
+		fm.TAxis = quakeMLAxis(&q.PrincipalAxes.TAxis)
+		fm.NAxis = quakeMLAxis(q.PrincipalAxes.NAxis)
+		fm.PAxis = quakeMLAxis(&q.PrincipalAxes.PAxis)
+	}
+
+	var tensor *Tensor
+	if len(q.MomentTensors) > 0 {
+		tensor = q.MomentTensors[0].tensor()
+	}
+
+	var np1, np2 *NodalPlane
+	if q.NodalPlanes != nil {
+		np1, np2 = quakeMLPlane(q.NodalPlanes.NodalPlane1), quakeMLPlane(q.NodalPlanes.NodalPlane2)
+	}
+
+	if err := fm.complete(np1, np2, tensor); err != nil {
+		return nil, err
+	}
+
+	return fm, nil
+
+}
+
+func (q *QuakeMLMomentTensor) tensor() *Tensor {
+	if q.Tensor == nil {
+		return nil
+	}
+	return &Tensor{
+		Mrr: q.Tensor.Mrr.Value,
+		Mtt: q.Tensor.Mtt.Value,
+		Mpp: q.Tensor.Mpp.Value,
+		Mrt: q.Tensor.Mrt.Value,
+		Mrp: q.Tensor.Mrp.Value,
+		Mtp: q.Tensor.Mtp.Value,
+	}
+}
+
+// the first moment tensor of the focal mechanism in typed form
+func (q *QuakeMLFocalMechanism) MomentTensor() (*MomentTensor, error) {
+
+	if len(q.MomentTensors) == 0 {
+		return nil, ErrNoMechanism
+	}
+	qmt := &q.MomentTensors[0]
+
+	fm, err := q.FocalMechanism()
+	if err != nil {
+		return nil, err
+	}
+
+	mt := &MomentTensor{
+		FocalMechanism: *fm,
+		Tensor:         qmt.tensor(),
+	}
+	if qmt.ScalarMoment != nil {
+		mt.ScalarMoment = qmt.ScalarMoment.Value
+	} else if mt.Tensor != nil {
+		mt.ScalarMoment = mt.Tensor.ScalarMoment()
+	}
+	if qmt.DoubleCouple != nil {
+		mt.PercentDoubleCouple = *qmt.DoubleCouple * 100
+	} else if mt.Tensor != nil {
+		mt.PercentDoubleCouple = mt.Tensor.PercentDoubleCouple()
+	}
+	if qmt.SourceTimeFunction != nil {
+		mt.SourceTimeDuration = qmt.SourceTimeFunction.Duration
+	}
+	if mt.ScalarMoment > 0 {
+		mt.DerivedMagnitude = MomentMagnitude(mt.ScalarMoment)
+		mt.DerivedMagnitudeType = "Mw"
+	}
+
+	return mt, nil
+
+}
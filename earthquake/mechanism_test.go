@@ -0,0 +1,238 @@
+package earthquake
+
+import (
+	"encoding/xml"
+	"math"
+	"strconv"
+	"testing"
+)
+
+func planesEqual(a, b NodalPlane) bool {
+	d := math.Abs(math.Mod(a.Strike-b.Strike+540, 360) - 180)
+	return d < 1e-6 && math.Abs(a.Dip-b.Dip) < 1e-6 && math.Abs(a.Rake-b.Rake) < 1e-6
+}
+
+// the tensor of a pure double couple on np with moment m0
+func doubleCouple(np NodalPlane, m0 float64) Tensor {
+	n, s := np.vectors()
+	var m [3][3]float64
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			m[i][j] = m0 * (n[i]*s[j] + s[i]*n[j])
+		}
+	}
+	return Tensor{Mrr: m[2][2], Mtt: m[0][0], Mpp: m[1][1], Mrt: m[0][2], Mrp: -m[1][2], Mtp: -m[0][1]}
+}
+
+func TestAuxiliaryPlane(t *testing.T) {
+
+	for _, tc := range []struct{ np, aux NodalPlane }{
+		{NodalPlane{0, 45, 90}, NodalPlane{180, 45, 90}},
+		{NodalPlane{0, 90, 0}, NodalPlane{270, 90, 180}},
+		{NodalPlane{350, 30, 90}, NodalPlane{170, 60, 90}},
+		{NodalPlane{40, 50, -90}, NodalPlane{220, 40, -90}},
+	} {
+		if aux := tc.np.AuxiliaryPlane(); !planesEqual(aux, tc.aux) {
+			t.Errorf("%v: expected %v, got %v", tc.np, tc.aux, aux)
+		}
+		if np := tc.np.AuxiliaryPlane().AuxiliaryPlane(); !planesEqual(np, tc.np) {
+			t.Errorf("%v: expected auxiliary of auxiliary to match, got %v", tc.np, np)
+		}
+	}
+
+}
+
+func TestTensorNodalPlanes(t *testing.T) {
+
+	for _, np := range []NodalPlane{
+		{30, 60, 90},
+		{120, 80, 10},
+		{200, 35, -110},
+	} {
+		tensor := doubleCouple(np, 1e18)
+
+		np1, np2 := tensor.NodalPlanes()
+		if !planesEqual(np1, np) && !planesEqual(np2, np) {
+			t.Errorf("%v: got %v and %v", np, np1, np2)
+		}
+		if m0 := tensor.ScalarMoment(); math.Abs(m0-1e18)/1e18 > 1e-9 {
+			t.Errorf("%v: expected scalar moment 1e18, got %v", np, m0)
+		}
+		if pdc := tensor.PercentDoubleCouple(); math.Abs(pdc-100) > 1e-6 {
+			t.Errorf("%v: expected 100%% double couple, got %v", np, pdc)
+		}
+
+		T, _, P := tensor.PrincipalAxes()
+		if T.Length <= 0 || P.Length >= 0 || T.Plunge < 0 || P.Plunge < 0 {
+			t.Errorf("%v: unexpected axes %+v %+v", np, T, P)
+		}
+	}
+
+	// a thrust has a vertical tension axis
+	T, _, _ := doubleCouple(NodalPlane{0, 45, 90}, 1).PrincipalAxes()
+	if math.Abs(T.Plunge-90) > 1e-6 {
+		t.Errorf("expected vertical T axis, got %+v", T)
+	}
+
+}
+
+func TestFaultType(t *testing.T) {
+
+	for rake, expected := range map[float64]FaultType{
+		90:   FaultTypeReverse,
+		-90:  FaultTypeNormal,
+		0:    FaultTypeStrikeSlip,
+		180:  FaultTypeStrikeSlip,
+		-170: FaultTypeStrikeSlip,
+		270:  FaultTypeNormal,
+		44:   FaultTypeStrikeSlip,
+		46:   FaultTypeReverse,
+	} {
+		if ft := (NodalPlane{Rake: rake}).FaultType(); ft != expected {
+			t.Errorf("rake %v: expected %s, got %s", rake, expected, ft)
+		}
+	}
+
+}
+
+func TestEventDetailMechanisms(t *testing.T) {
+
+	var e EventDetail
+	if _, err := e.MomentTensor(); err != ErrNoMechanism {
+		t.Errorf("expected %v, got %v", ErrNoMechanism, err)
+	}
+
+	tensor := doubleCouple(NodalPlane{30, 60, 90}, 3.5e17)
+	format := func(v float64) string { return strconv.FormatFloat(v, 'g', -1, 64) }
+
+	e.Properties.Products = map[ProductType][]Product{
+		ProductTypeMomentTensor: {{
+			Source: "us",
+			Code:   "us_2000ck9q_mww",
+			Properties: map[string]string{
+				"tensor-mrr":             format(tensor.Mrr),
+				"tensor-mtt":             format(tensor.Mtt),
+				"tensor-mpp":             format(tensor.Mpp),
+				"tensor-mrt":             format(tensor.Mrt),
+				"tensor-mrp":             format(tensor.Mrp),
+				"tensor-mtp":             format(tensor.Mtp),
+				"derived-magnitude":      "5.63",
+				"derived-magnitude-type": "Mww",
+				"percent-double-couple":  "0.95",
+				"nodal-plane-1-strike":   "30",
+				"nodal-plane-1-dip":      "60",
+				"nodal-plane-1-rake":     "90",
+			},
+		}},
+		ProductTypeFocalMechanism: {{
+			Source: "ci",
+			Properties: map[string]string{
+				"nodal-plane-1-strike": "40",
+				"nodal-plane-1-dip":    "50",
+				"nodal-plane-1-slip":   "-90",
+			},
+		}},
+	}
+
+	mt, err := e.MomentTensor()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mt.DerivedMagnitude != 5.63 || mt.DerivedMagnitudeType != "Mww" || mt.PercentDoubleCouple != 95 {
+		t.Errorf("unexpected moment tensor %+v", mt)
+	}
+	if math.Abs(mt.ScalarMoment-3.5e17)/3.5e17 > 1e-6 {
+		t.Errorf("expected scalar moment 3.5e17, got %v", mt.ScalarMoment)
+	}
+	if !planesEqual(mt.NodalPlane2, NodalPlane{210, 30, 90}) {
+		t.Errorf("unexpected nodal plane 2 %v", mt.NodalPlane2)
+	}
+	if mt.TAxis == nil || mt.FaultType() != FaultTypeReverse {
+		t.Errorf("unexpected mechanism %+v", mt.FocalMechanism)
+	}
+
+	fm, err := e.FocalMechanism()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fm.Source != "ci" || fm.FaultType() != FaultTypeNormal || !planesEqual(fm.NodalPlane2, NodalPlane{220, 40, -90}) {
+		t.Errorf("unexpected focal mechanism %+v", fm)
+	}
+
+}
+
+func TestQuakeMLMechanism(t *testing.T) {
+
+	var q QuakeML
+	if err := xml.Unmarshal([]byte(testQuakeML), &q); err != nil {
+		t.Fatal(err)
+	}
+	qfm := q.EventParameters.Events[0].PreferredFocalMechanism()
+
+	fm, err := qfm.FocalMechanism()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !planesEqual(fm.NodalPlane1, NodalPlane{350, 30, 90}) || fm.TAxis == nil || fm.NAxis == nil {
+		t.Errorf("unexpected focal mechanism %+v", fm)
+	}
+	if fm.Source != "us" || fm.Code != "us_2000ck9q_mww" {
+		t.Errorf("expected us us_2000ck9q_mww, got %q %q", fm.Source, fm.Code)
+	}
+
+	// only the second plane, the first is derived as with products
+	only2 := QuakeMLFocalMechanism{NodalPlanes: &QuakeMLNodalPlanes{NodalPlane2: qfm.NodalPlanes.NodalPlane2}}
+	fm, err = only2.FocalMechanism()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pfm, err := NewFocalMechanism(&Product{Properties: map[string]string{
+		"nodal-plane-2-strike": "170",
+		"nodal-plane-2-dip":    "60",
+		"nodal-plane-2-rake":   "90",
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !planesEqual(fm.NodalPlane1, NodalPlane{350, 30, 90}) || !planesEqual(fm.NodalPlane2, NodalPlane{170, 60, 90}) ||
+		!planesEqual(fm.NodalPlane1, pfm.NodalPlane1) || !planesEqual(fm.NodalPlane2, pfm.NodalPlane2) {
+		t.Errorf("unexpected focal mechanism %+v, product %+v", fm, pfm)
+	}
+
+	mt, err := qfm.MomentTensor()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mt.ScalarMoment != 3.5e15 || mt.PercentDoubleCouple != 92 || mt.Tensor == nil {
+		t.Errorf("unexpected moment tensor %+v", mt)
+	}
+	if math.Abs(mt.DerivedMagnitude-4.3) > 0.05 {
+		t.Errorf("expected Mw 4.3, got %v", mt.DerivedMagnitude)
+	}
+
+}
+
+func TestPercentDoubleCouple(t *testing.T) {
+
+	for pdc, expected := range map[string]float64{
+		"0.95": 95,
+		"0.5":  50,
+		"1":    1,
+		"1.5":  1.5,
+		"95":   95,
+	} {
+		mt, err := NewMomentTensor(&Product{Properties: map[string]string{
+			"nodal-plane-1-strike":  "30",
+			"nodal-plane-1-dip":     "60",
+			"nodal-plane-1-rake":    "90",
+			"percent-double-couple": pdc,
+		}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if mt.PercentDoubleCouple != expected {
+			t.Errorf("%s: expected %v, got %v", pdc, expected, mt.PercentDoubleCouple)
+		}
+	}
+
+}
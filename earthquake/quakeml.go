@@ -0,0 +1,367 @@
+package earthquake
+
+import (
+	"context"
+	"encoding/xml"
+	"time"
+)
+
+// QuakeML 1.2 Basic Event Description
+// https://quake.ethz.ch/quakeml/docs/REC?action=AttachFile&do=get&target=QuakeML-BED-20130214b.pdf
+// Only the parts of the schema produced by the fdsnws event service are modeled.
+type (
+	QuakeML struct {
+		XMLName         xml.Name               `xml:"quakeml"`
+		EventParameters QuakeMLEventParameters `xml:"eventParameters"`
+	}
+
+	QuakeMLEventParameters struct {
+		PublicID     string               `xml:"publicID,attr"`
+		Events       []QuakeMLEvent       `xml:"event"`
+		CreationInfo *QuakeMLCreationInfo `xml:"creationInfo"`
+	}
+
+	QuakeMLEvent struct {
+		PublicID    string `xml:"publicID,attr"`
+		DataSource  string `xml:"http://anss.org/xmlns/catalog/0.1 datasource,attr"`
+		EventSource string `xml:"http://anss.org/xmlns/catalog/0.1 eventsource,attr"`
+		EventID     string `xml:"http://anss.org/xmlns/catalog/0.1 eventid,attr"`
+
+		PreferredOriginID         string                    `xml:"preferredOriginID"`
+		PreferredMagnitudeID      string                    `xml:"preferredMagnitudeID"`
+		PreferredFocalMechanismID string                    `xml:"preferredFocalMechanismID"`
+		Type                      string                    `xml:"type"`
+		TypeCertainty             string                    `xml:"typeCertainty"`
+		Descriptions              []QuakeMLEventDescription `xml:"description"`
+		Comments                  []QuakeMLComment          `xml:"comment"`
+		CreationInfo              *QuakeMLCreationInfo      `xml:"creationInfo"`
+
+		Origins           []QuakeMLOrigin           `xml:"origin"`
+		Magnitudes        []QuakeMLMagnitude        `xml:"magnitude"`
+		StationMagnitudes []QuakeMLStationMagnitude `xml:"stationMagnitude"`
+		Picks             []QuakeMLPick             `xml:"pick"`
+		Amplitudes        []QuakeMLAmplitude        `xml:"amplitude"`
+		FocalMechanisms   []QuakeMLFocalMechanism   `xml:"focalMechanism"`
+	}
+
+	QuakeMLEventDescription struct {
+		Text string `xml:"text"`
+		Type string `xml:"type"`
+	}
+
+	QuakeMLComment struct {
+		ID   string `xml:"id,attr"`
+		Text string `xml:"text"`
+	}
+
+	QuakeMLCreationInfo struct {
+		AgencyID     string    `xml:"agencyID"`
+		Author       string    `xml:"author"`
+		CreationTime time.Time `xml:"creationTime"`
+		Version      string    `xml:"version"`
+	}
+
+	QuakeMLRealQuantity struct {
+		Value            float64  `xml:"value"`
+		Uncertainty      *float64 `xml:"uncertainty"`
+		LowerUncertainty *float64 `xml:"lowerUncertainty"`
+		UpperUncertainty *float64 `xml:"upperUncertainty"`
+		ConfidenceLevel  *float64 `xml:"confidenceLevel"`
+	}
+
+	QuakeMLIntegerQuantity struct {
+		Value       int  `xml:"value"`
+		Uncertainty *int `xml:"uncertainty"`
+	}
+
+	QuakeMLTimeQuantity struct {
+		Value       time.Time `xml:"value"`
+		Uncertainty *float64  `xml:"uncertainty"`
+	}
+
+	QuakeMLWaveformID struct {
+		NetworkCode  string `xml:"networkCode,attr"`
+		StationCode  string `xml:"stationCode,attr"`
+		ChannelCode  string `xml:"channelCode,attr"`
+		LocationCode string `xml:"locationCode,attr"`
+	}
+
+	// NOTE: depth and its uncertainty are in meters
+	QuakeMLOrigin struct {
+		PublicID          string                    `xml:"publicID,attr"`
+		Time              QuakeMLTimeQuantity       `xml:"time"`
+		Latitude          QuakeMLRealQuantity       `xml:"latitude"`
+		Longitude         QuakeMLRealQuantity       `xml:"longitude"`
+		Depth             *QuakeMLRealQuantity      `xml:"depth"`
+		DepthType         string                    `xml:"depthType"`
+		TimeFixed         bool                      `xml:"timeFixed"`
+		EpicenterFixed    bool                      `xml:"epicenterFixed"`
+		MethodID          string                    `xml:"methodID"`
+		EarthModelID      string                    `xml:"earthModelID"`
+		Type              string                    `xml:"type"`
+		Region            string                    `xml:"region"`
+		EvaluationMode    string                    `xml:"evaluationMode"`
+		EvaluationStatus  string                    `xml:"evaluationStatus"`
+		OriginUncertainty *QuakeMLOriginUncertainty `xml:"originUncertainty"`
+		Quality           *QuakeMLOriginQuality     `xml:"quality"`
+		Arrivals          []QuakeMLArrival          `xml:"arrival"`
+		Comments          []QuakeMLComment          `xml:"comment"`
+		CreationInfo      *QuakeMLCreationInfo      `xml:"creationInfo"`
+	}
+
+	// NOTE: horizontal uncertainties are in meters
+	QuakeMLOriginUncertainty struct {
+		HorizontalUncertainty           *float64                    `xml:"horizontalUncertainty"`
+		MinHorizontalUncertainty        *float64                    `xml:"minHorizontalUncertainty"`
+		MaxHorizontalUncertainty        *float64                    `xml:"maxHorizontalUncertainty"`
+		AzimuthMaxHorizontalUncertainty *float64                    `xml:"azimuthMaxHorizontalUncertainty"`
+		ConfidenceEllipsoid             *QuakeMLConfidenceEllipsoid `xml:"confidenceEllipsoid"`
+		PreferredDescription            string                      `xml:"preferredDescription"`
+		ConfidenceLevel                 *float64                    `xml:"confidenceLevel"`
+	}
+
+	QuakeMLConfidenceEllipsoid struct {
+		SemiMajorAxisLength        float64 `xml:"semiMajorAxisLength"`
+		SemiMinorAxisLength        float64 `xml:"semiMinorAxisLength"`
+		SemiIntermediateAxisLength float64 `xml:"semiIntermediateAxisLength"`
+		MajorAxisPlunge            float64 `xml:"majorAxisPlunge"`
+		MajorAxisAzimuth           float64 `xml:"majorAxisAzimuth"`
+		MajorAxisRotation          float64 `xml:"majorAxisRotation"`
+	}
+
+	QuakeMLOriginQuality struct {
+		AssociatedPhaseCount   *int     `xml:"associatedPhaseCount"`
+		UsedPhaseCount         *int     `xml:"usedPhaseCount"`
+		AssociatedStationCount *int     `xml:"associatedStationCount"`
+		UsedStationCount       *int     `xml:"usedStationCount"`
+		DepthPhaseCount        *int     `xml:"depthPhaseCount"`
+		StandardError          *float64 `xml:"standardError"`
+		AzimuthalGap           *float64 `xml:"azimuthalGap"`
+		SecondaryAzimuthalGap  *float64 `xml:"secondaryAzimuthalGap"`
+		GroundTruthLevel       string   `xml:"groundTruthLevel"`
+		MaximumDistance        *float64 `xml:"maximumDistance"`
+		MinimumDistance        *float64 `xml:"minimumDistance"`
+		MedianDistance         *float64 `xml:"medianDistance"`
+	}
+
+	QuakeMLArrival struct {
+		PublicID                   string               `xml:"publicID,attr"`
+		PickID                     string               `xml:"pickID"`
+		Phase                      string               `xml:"phase"`
+		Azimuth                    *float64             `xml:"azimuth"`
+		Distance                   *float64             `xml:"distance"`
+		TakeoffAngle               *QuakeMLRealQuantity `xml:"takeoffAngle"`
+		TimeResidual               *float64             `xml:"timeResidual"`
+		HorizontalSlownessResidual *float64             `xml:"horizontalSlownessResidual"`
+		BackazimuthResidual        *float64             `xml:"backazimuthResidual"`
+		TimeWeight                 *float64             `xml:"timeWeight"`
+		EarthModelID               string               `xml:"earthModelID"`
+		CreationInfo               *QuakeMLCreationInfo `xml:"creationInfo"`
+	}
+
+	QuakeMLPick struct {
+		PublicID         string               `xml:"publicID,attr"`
+		Time             QuakeMLTimeQuantity  `xml:"time"`
+		WaveformID       QuakeMLWaveformID    `xml:"waveformID"`
+		MethodID         string               `xml:"methodID"`
+		BackAzimuth      *QuakeMLRealQuantity `xml:"backazimuth"`
+		Onset            string               `xml:"onset"`
+		PhaseHint        string               `xml:"phaseHint"`
+		Polarity         string               `xml:"polarity"`
+		EvaluationMode   string               `xml:"evaluationMode"`
+		EvaluationStatus string               `xml:"evaluationStatus"`
+		CreationInfo     *QuakeMLCreationInfo `xml:"creationInfo"`
+	}
+
+	QuakeMLMagnitude struct {
+		PublicID                      string                                `xml:"publicID,attr"`
+		Mag                           QuakeMLRealQuantity                   `xml:"mag"`
+		Type                          string                                `xml:"type"`
+		OriginID                      string                                `xml:"originID"`
+		MethodID                      string                                `xml:"methodID"`
+		StationCount                  *int                                  `xml:"stationCount"`
+		AzimuthalGap                  *float64                              `xml:"azimuthalGap"`
+		EvaluationMode                string                                `xml:"evaluationMode"`
+		EvaluationStatus              string                                `xml:"evaluationStatus"`
+		StationMagnitudeContributions []QuakeMLStationMagnitudeContribution `xml:"stationMagnitudeContribution"`
+		Comments                      []QuakeMLComment                      `xml:"comment"`
+		CreationInfo                  *QuakeMLCreationInfo                  `xml:"creationInfo"`
+	}
+
+	QuakeMLStationMagnitudeContribution struct {
+		StationMagnitudeID string   `xml:"stationMagnitudeID"`
+		Residual           *float64 `xml:"residual"`
+		Weight             *float64 `xml:"weight"`
+	}
+
+	QuakeMLStationMagnitude struct {
+		PublicID     string               `xml:"publicID,attr"`
+		OriginID     string               `xml:"originID"`
+		Mag          QuakeMLRealQuantity  `xml:"mag"`
+		Type         string               `xml:"type"`
+		AmplitudeID  string               `xml:"amplitudeID"`
+		MethodID     string               `xml:"methodID"`
+		WaveformID   *QuakeMLWaveformID   `xml:"waveformID"`
+		CreationInfo *QuakeMLCreationInfo `xml:"creationInfo"`
+	}
+
+	QuakeMLAmplitude struct {
+		PublicID         string               `xml:"publicID,attr"`
+		GenericAmplitude QuakeMLRealQuantity  `xml:"genericAmplitude"`
+		Type             string               `xml:"type"`
+		Unit             string               `xml:"unit"`
+		Period           *QuakeMLRealQuantity `xml:"period"`
+		PickID           string               `xml:"pickID"`
+		WaveformID       *QuakeMLWaveformID   `xml:"waveformID"`
+		EvaluationMode   string               `xml:"evaluationMode"`
+		CreationInfo     *QuakeMLCreationInfo `xml:"creationInfo"`
+	}
+
+	QuakeMLFocalMechanism struct {
+		PublicID                 string                `xml:"publicID,attr"`
+		DataSource               string                `xml:"http://anss.org/xmlns/catalog/0.1 datasource,attr"`
+		DataID                   string                `xml:"http://anss.org/xmlns/catalog/0.1 dataid,attr"`
+		TriggeringOriginID       string                `xml:"triggeringOriginID"`
+		NodalPlanes              *QuakeMLNodalPlanes   `xml:"nodalPlanes"`
+		PrincipalAxes            *QuakeMLPrincipalAxes `xml:"principalAxes"`
+		AzimuthalGap             *float64              `xml:"azimuthalGap"`
+		StationPolarityCount     *int                  `xml:"stationPolarityCount"`
+		Misfit                   *float64              `xml:"misfit"`
+		StationDistributionRatio *float64              `xml:"stationDistributionRatio"`
+		MethodID                 string                `xml:"methodID"`
+		EvaluationMode           string                `xml:"evaluationMode"`
+		EvaluationStatus         string                `xml:"evaluationStatus"`
+		MomentTensors            []QuakeMLMomentTensor `xml:"momentTensor"`
+		Comments                 []QuakeMLComment      `xml:"comment"`
+		CreationInfo             *QuakeMLCreationInfo  `xml:"creationInfo"`
+	}
+
+	QuakeMLNodalPlanes struct {
+		PreferredPlane int                `xml:"preferredPlane,attr"`
+		NodalPlane1    *QuakeMLNodalPlane `xml:"nodalPlane1"`
+		NodalPlane2    *QuakeMLNodalPlane `xml:"nodalPlane2"`
+	}
+
+	// angles in degrees
+	QuakeMLNodalPlane struct {
+		Strike QuakeMLRealQuantity `xml:"strike"`
+		Dip    QuakeMLRealQuantity `xml:"dip"`
+		Rake   QuakeMLRealQuantity `xml:"rake"`
+	}
+
+	QuakeMLPrincipalAxes struct {
+		TAxis QuakeMLAxis  `xml:"tAxis"`
+		PAxis QuakeMLAxis  `xml:"pAxis"`
+		NAxis *QuakeMLAxis `xml:"nAxis"`
+	}
+
+	// azimuth and plunge in degrees, length in Nm
+	QuakeMLAxis struct {
+		Azimuth QuakeMLRealQuantity `xml:"azimuth"`
+		Plunge  QuakeMLRealQuantity `xml:"plunge"`
+		Length  QuakeMLRealQuantity `xml:"length"`
+	}
+
+	QuakeMLMomentTensor struct {
+		PublicID           string                     `xml:"publicID,attr"`
+		DerivedOriginID    string                     `xml:"derivedOriginID"`
+		MomentMagnitudeID  string                     `xml:"momentMagnitudeID"`
+		ScalarMoment       *QuakeMLRealQuantity       `xml:"scalarMoment"`
+		Tensor             *QuakeMLTensor             `xml:"tensor"`
+		Variance           *float64                   `xml:"variance"`
+		VarianceReduction  *float64                   `xml:"varianceReduction"`
+		DoubleCouple       *float64                   `xml:"doubleCouple"`
+		CLVD               *float64                   `xml:"clvd"`
+		ISO                *float64                   `xml:"iso"`
+		SourceTimeFunction *QuakeMLSourceTimeFunction `xml:"sourceTimeFunction"`
+		MethodID           string                     `xml:"methodID"`
+		Category           string                     `xml:"category"`
+		InversionType      string                     `xml:"inversionType"`
+		CreationInfo       *QuakeMLCreationInfo       `xml:"creationInfo"`
+	}
+
+	// moment tensor components in Nm
+	QuakeMLTensor struct {
+		Mrr QuakeMLRealQuantity `xml:"Mrr"`
+		Mtt QuakeMLRealQuantity `xml:"Mtt"`
+		Mpp QuakeMLRealQuantity `xml:"Mpp"`
+		Mrt QuakeMLRealQuantity `xml:"Mrt"`
+		Mrp QuakeMLRealQuantity `xml:"Mrp"`
+		Mtp QuakeMLRealQuantity `xml:"Mtp"`
+	}
+
+	QuakeMLSourceTimeFunction struct {
+		Type      string   `xml:"type"`
+		Duration  float64  `xml:"duration"`
+		RiseTime  *float64 `xml:"riseTime"`
+		DecayTime *float64 `xml:"decayTime"`
+	}
+)
+
+// the origin referenced by PreferredOriginID, or nil
+func (e *QuakeMLEvent) PreferredOrigin() *QuakeMLOrigin {
+	for i := range e.Origins {
+		if e.Origins[i].PublicID == e.PreferredOriginID {
+			return &e.Origins[i]
+		}
+	}
+	return nil
+}
+
+// the magnitude referenced by PreferredMagnitudeID, or nil
+func (e *QuakeMLEvent) PreferredMagnitude() *QuakeMLMagnitude {
+	for i := range e.Magnitudes {
+		if e.Magnitudes[i].PublicID == e.PreferredMagnitudeID {
+			return &e.Magnitudes[i]
+		}
+	}
+	return nil
+}
+
+// the focal mechanism referenced by PreferredFocalMechanismID, or nil
+func (e *QuakeMLEvent) PreferredFocalMechanism() *QuakeMLFocalMechanism {
+	for i := range e.FocalMechanisms {
+		if e.FocalMechanisms[i].PublicID == e.PreferredFocalMechanismID {
+			return &e.FocalMechanisms[i]
+		}
+	}
+	return nil
+}
+
+// the pick referenced by an arrival, or nil
+func (e *QuakeMLEvent) Pick(id string) *QuakeMLPick {
+	for i := range e.Picks {
+		if e.Picks[i].PublicID == id {
+			return &e.Picks[i]
+		}
+	}
+	return nil
+}
+
+// submit a data request and decode the QuakeML response.  The format of qp is ignored.
+// A request matching no data returns an empty QuakeML.
+func (c *Client) GetQueryQuakeML(qp *queryParameters) (*QuakeML, error) {
+	return c.GetQueryQuakeMLContext(context.Background(), qp)
+}
+
+func (c *Client) GetQueryQuakeMLContext(ctx context.Context, qp *queryParameters) (*QuakeML, error) {
+	// https://earthquake.usgs.gov/fdsnws/event/1/query?format=xml&eventid=us2000ck9q
+	xqp := *qp
+	xqp.Format = FormatXML
+	if err := xqp.Validate(); err != nil {
+		return nil, err
+	}
+	resp, err := c.get(ctx, "/query?"+xqp.Encode())
+	if xqp.isNoData(err) {
+		return &QuakeML{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var v QuakeML
+	if err := xml.NewDecoder(resp.Body).Decode(&v); err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
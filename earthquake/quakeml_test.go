@@ -0,0 +1,99 @@
+package earthquake
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+const testQuakeML = `<?xml version="1.0" encoding="UTF-8"?>
+<q:quakeml xmlns:q="http://quakeml.org/xmlns/quakeml/1.2" xmlns="http://quakeml.org/xmlns/bed/1.2" xmlns:catalog="http://anss.org/xmlns/catalog/0.1">
+<eventParameters publicID="quakeml:earthquake.usgs.gov/fdsnws/event/1/query">
+<event catalog:datasource="us" catalog:eventsource="us" catalog:eventid="2000ck9q" publicID="quakeml:earthquake.usgs.gov/fdsnws/event/1/query?eventid=us2000ck9q">
+<description><type>earthquake name</type><text>52km SSE of Ollague, Chile</text></description>
+<origin publicID="quakeml:us.anss.org/origin/2000ck9q">
+<originUncertainty><horizontalUncertainty>10400</horizontalUncertainty><preferredDescription>horizontal uncertainty</preferredDescription></originUncertainty>
+<time><value>2018-01-02T03:55:52.080Z</value></time>
+<longitude><value>-68.7707</value></longitude>
+<latitude><value>-21.1179</value></latitude>
+<depth><value>117920</value><uncertainty>7900</uncertainty></depth>
+<quality><usedPhaseCount>46</usedPhaseCount><standardError>0.87</standardError><azimuthalGap>104</azimuthalGap><minimumDistance>0.789</minimumDistance></quality>
+<evaluationMode>manual</evaluationMode>
+<arrival publicID="quakeml:us.anss.org/arrival/1"><pickID>quakeml:us.anss.org/pick/1</pickID><phase>P</phase><azimuth>12.5</azimuth><distance>0.8</distance><timeResidual>-0.3</timeResidual><timeWeight>1</timeWeight></arrival>
+</origin>
+<pick publicID="quakeml:us.anss.org/pick/1"><time><value>2018-01-02T03:56:10.000Z</value></time><waveformID networkCode="C" stationCode="GO01" channelCode="BHZ" locationCode="--"/><phaseHint>P</phaseHint><evaluationMode>manual</evaluationMode></pick>
+<magnitude publicID="quakeml:us.anss.org/magnitude/2000ck9q/mb"><mag><value>4.3</value><uncertainty>0.119</uncertainty></mag><type>mb</type><stationCount>20</stationCount><originID>quakeml:us.anss.org/origin/2000ck9q</originID></magnitude>
+<focalMechanism catalog:datasource="us" catalog:dataid="us_2000ck9q_mww" publicID="quakeml:us.anss.org/focalmechanism/1">
+<nodalPlanes preferredPlane="1"><nodalPlane1><strike><value>350</value></strike><dip><value>30</value></dip><rake><value>90</value></rake></nodalPlane1><nodalPlane2><strike><value>170</value></strike><dip><value>60</value></dip><rake><value>90</value></rake></nodalPlane2></nodalPlanes>
+<momentTensor publicID="quakeml:us.anss.org/momenttensor/1"><scalarMoment><value>3.5e15</value></scalarMoment><tensor><Mrr><value>3.1e15</value></Mrr><Mtt><value>-1.2e15</value></Mtt><Mpp><value>-1.9e15</value></Mpp><Mrt><value>1e14</value></Mrt><Mrp><value>2e14</value></Mrp><Mtp><value>3e14</value></Mtp></tensor><doubleCouple>0.92</doubleCouple></momentTensor>
+</focalMechanism>
+<preferredOriginID>quakeml:us.anss.org/origin/2000ck9q</preferredOriginID>
+<preferredMagnitudeID>quakeml:us.anss.org/magnitude/2000ck9q/mb</preferredMagnitudeID>
+<preferredFocalMechanismID>quakeml:us.anss.org/focalmechanism/1</preferredFocalMechanismID>
+<type>earthquake</type>
+</event>
+</eventParameters>
+</q:quakeml>`
+
+func TestGetQueryQuakeML(t *testing.T) {
+
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if f := r.URL.Query().Get("format"); f != "xml" {
+			t.Errorf("expected format xml, got %q", f)
+		}
+		w.Write([]byte(testQuakeML))
+	})
+
+	qp := NewQueryParameters()
+	qp.EventID = "us2000ck9q"
+
+	resp, err := c.GetQueryQuakeML(qp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if qp.Format != FormatGeoJSON {
+		t.Errorf("expected qp to be unmodified, got format %q", qp.Format)
+	}
+	if len(resp.EventParameters.Events) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(resp.EventParameters.Events))
+	}
+
+	e := resp.EventParameters.Events[0]
+	if e.EventID != "2000ck9q" || e.DataSource != "us" {
+		t.Errorf("unexpected catalog attributes %q %q", e.EventID, e.DataSource)
+	}
+
+	o := e.PreferredOrigin()
+	if o == nil {
+		t.Fatal("expected preferred origin")
+	}
+	if !o.Time.Value.Equal(time.Date(2018, 1, 2, 3, 55, 52, 80e6, time.UTC)) {
+		t.Errorf("unexpected origin time %v", o.Time.Value)
+	}
+	if o.Depth == nil || o.Depth.Value != 117920 || o.Depth.Uncertainty == nil || *o.Depth.Uncertainty != 7900 {
+		t.Errorf("unexpected depth %+v", o.Depth)
+	}
+	if o.Quality == nil || o.Quality.UsedPhaseCount == nil || *o.Quality.UsedPhaseCount != 46 {
+		t.Errorf("unexpected quality %+v", o.Quality)
+	}
+	if len(o.Arrivals) != 1 {
+		t.Fatalf("expected 1 arrival, got %d", len(o.Arrivals))
+	}
+	if p := e.Pick(o.Arrivals[0].PickID); p == nil || p.WaveformID.StationCode != "GO01" {
+		t.Errorf("unexpected pick %+v", p)
+	}
+
+	m := e.PreferredMagnitude()
+	if m == nil || m.Mag.Value != 4.3 || m.Type != "mb" {
+		t.Errorf("unexpected magnitude %+v", m)
+	}
+
+	fm := e.PreferredFocalMechanism()
+	if fm == nil || fm.NodalPlanes == nil || fm.NodalPlanes.NodalPlane2.Dip.Value != 60 {
+		t.Fatalf("unexpected focal mechanism %+v", fm)
+	}
+	if len(fm.MomentTensors) != 1 || fm.MomentTensors[0].Tensor.Mrr.Value != 3.1e15 {
+		t.Errorf("unexpected moment tensor %+v", fm.MomentTensors)
+	}
+
+}
@@ -1,7 +1,9 @@
 package earthquake
 
 import (
+	"fmt"
 	"math"
+	"net/http"
 	"net/url"
 	"strconv"
 	"time"
@@ -22,8 +24,9 @@ type (
 		// format=text     Response format is plain text. Mime-type is “text/plain”.
 		// format=xml      The xml format is dependent upon the request method used.
 		//
-		// Note(jasonmoo): currently only geoJSON is supported by this library
-		format string
+		// NOTE: GetQuery decodes geojson and csv, other formats have dedicated methods.
+		// Defaults to geojson.
+		Format Format
 
 		// Time
 		// All times use ISO8601 Date/Time format. Unless a timezone is specified, UTC is assumed.
@@ -105,6 +108,10 @@ type (
 		// NOTE(jasonmoo): adding TotalResults as a way to handle paged querying, it is ignored in non-paged query
 		TotalResults int
 
+		// Concurrency is the number of pages fetched in parallel by a paged query.
+		// Pages are still delivered in offset order. It is ignored in non-paged query
+		Concurrency int
+
 		// Extensions
 		// parameter	type	default	description
 		// alertlevel   String  null    [green, yellow, orange, red] Limit to events with a specific PAGER alert level.
@@ -133,9 +140,17 @@ type (
 		MinFelt      int
 		MinGap       float64
 		MinSig       int
+		NoData       int
 		ProductType  ProductType
 		ProductCode  string
 		ReviewStatus ReviewStatus
+
+		// Format Specific
+		// parameter    type     default  description
+		// kmlcolorby   String   age      [age, depth] How earthquakes are colored.
+		// kmlanimated  Boolean  false    Whether to include timestamp in generated kml, for google earth animation support.
+		KMLColorBy  KMLColorBy
+		KMLAnimated bool
 	}
 
 	Catalog       string
@@ -144,12 +159,22 @@ type (
 	MagnitudeType string
 	ProductType   string
 
+	Format       string
+	KMLColorBy   string
 	Order        string
 	AlertLevel   string
 	ReviewStatus string
 )
 
 const (
+	FormatCSV             Format       = "csv"
+	FormatGeoJSON         Format       = "geojson"
+	FormatKML             Format       = "kml"
+	FormatQuakeML         Format       = "quakeml"
+	FormatText            Format       = "text"
+	FormatXML             Format       = "xml"
+	KMLColorByAge         KMLColorBy   = "age"
+	KMLColorByDepth       KMLColorBy   = "depth"
 	OrderTimeDesc         Order        = "time"
 	OrderTimeAsc          Order        = "time-asc"
 	OrderMagnitudeDesc    Order        = "magnitude"
@@ -165,7 +190,7 @@ const (
 
 func NewQueryParameters() *queryParameters {
 	return &queryParameters{
-		format:       "geojson",
+		Format:       FormatGeoJSON,
 		MinLatitude:  math.NaN(),
 		MinLongitude: math.NaN(),
 		MaxLatitude:  math.NaN(),
@@ -188,15 +213,15 @@ func NewQueryParameters() *queryParameters {
 
 func (qp *queryParameters) Encode() string {
 	v := make(url.Values)
-	v.Set("format", qp.format)
+	v.Set("format", string(qp.Format))
 	if !qp.StartTime.IsZero() {
-		v.Set("starttime", qp.StartTime.UTC().Format(time.RFC3339))
+		v.Set("starttime", qp.StartTime.UTC().Format(time.RFC3339Nano))
 	}
 	if !qp.EndTime.IsZero() {
-		v.Set("endtime", qp.EndTime.UTC().Format(time.RFC3339))
+		v.Set("endtime", qp.EndTime.UTC().Format(time.RFC3339Nano))
 	}
 	if !qp.UpdatedAfter.IsZero() {
-		v.Set("updatedafter", qp.UpdatedAfter.UTC().Format(time.RFC3339))
+		v.Set("updatedafter", qp.UpdatedAfter.UTC().Format(time.RFC3339Nano))
 	}
 	if !math.IsNaN(qp.MinLatitude) {
 		v.Set("minlatitude", strconv.FormatFloat(qp.MinLatitude, 'f', -1, 64))
@@ -294,6 +319,9 @@ func (qp *queryParameters) Encode() string {
 	if qp.MinSig != 0 {
 		v.Set("minsig", strconv.Itoa(qp.MinSig))
 	}
+	if qp.NoData != 0 {
+		v.Set("nodata", strconv.Itoa(qp.NoData))
+	}
 	if qp.ProductType != "" {
 		v.Set("producttype", string(qp.ProductType))
 	}
@@ -303,5 +331,138 @@ func (qp *queryParameters) Encode() string {
 	if qp.ReviewStatus != "" {
 		v.Set("reviewstatus", string(qp.ReviewStatus))
 	}
+	if qp.KMLColorBy != "" {
+		v.Set("kmlcolorby", string(qp.KMLColorBy))
+	}
+	if qp.KMLAnimated {
+		v.Set("kmlanimated", "true")
+	}
 	return v.Encode()
 }
+
+// reports whether err is the response to qp matching no data, a 204 or a 404
+// when requested with nodata=404.
+func (qp *queryParameters) isNoData(err error) bool {
+	switch statusCode(err) {
+	case http.StatusNoContent:
+		return true
+	case http.StatusNotFound:
+		return qp.NoData == http.StatusNotFound
+	}
+	return false
+}
+
+// check the documented constraints of the parameters, returning ValidationErrors
+// listing each violated rule.  Called by the query methods before sending a request.
+func (qp *queryParameters) Validate() error {
+
+	var errs ValidationErrors
+	fail := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+	set := func(v float64) bool { return !math.IsNaN(v) }
+	inRange := func(name string, v, min, max float64) {
+		if set(v) && (v < min || v > max) {
+			fail("%s %v outside of [%v, %v]", name, v, min, max)
+		}
+	}
+	ordered := func(minName string, min float64, maxName string, max float64, strict bool) {
+		if set(min) && set(max) && (min > max || strict && min == max) {
+			fail("%s %v must be less than %s %v", minName, min, maxName, max)
+		}
+	}
+	oneOf := func(name, v string, valid ...string) {
+		if v == "" {
+			return
+		}
+		for _, s := range valid {
+			if v == s {
+				return
+			}
+		}
+		fail("%s %q not one of %q", name, v, valid)
+	}
+
+	// Formats
+	oneOf("format", string(qp.Format), string(FormatCSV), string(FormatGeoJSON), string(FormatKML), string(FormatQuakeML), string(FormatText), string(FormatXML))
+
+	// Time
+	if !qp.StartTime.IsZero() && !qp.EndTime.IsZero() && qp.StartTime.After(qp.EndTime) {
+		fail("starttime %s is after endtime %s", qp.StartTime.Format(time.RFC3339Nano), qp.EndTime.Format(time.RFC3339Nano))
+	}
+
+	// Rectangle
+	inRange("minlatitude", qp.MinLatitude, -90, 90)
+	inRange("maxlatitude", qp.MaxLatitude, -90, 90)
+	inRange("minlongitude", qp.MinLongitude, -360, 360)
+	inRange("maxlongitude", qp.MaxLongitude, -360, 360)
+	ordered("minlatitude", qp.MinLatitude, "maxlatitude", qp.MaxLatitude, true)
+	ordered("minlongitude", qp.MinLongitude, "maxlongitude", qp.MaxLongitude, true)
+
+	// Circle
+	inRange("latitude", qp.Latitude, -90, 90)
+	inRange("longitude", qp.Longitude, -180, 180)
+	inRange("maxradius", qp.MaxRadius, 0, 180)
+	inRange("maxradiuskm", qp.MaxRadiusKM, 0, 20001.6)
+	if set(qp.MaxRadius) && set(qp.MaxRadiusKM) {
+		fail("maxradius and maxradiuskm are mutually exclusive")
+	}
+	if circle := set(qp.Latitude) || set(qp.Longitude) || set(qp.MaxRadius) || set(qp.MaxRadiusKM); circle &&
+		!(set(qp.Latitude) && set(qp.Longitude) && (set(qp.MaxRadius) || set(qp.MaxRadiusKM))) {
+		fail("circle search requires latitude, longitude and maxradius or maxradiuskm")
+	}
+
+	// Other
+	if qp.IncludeSuperseded && qp.EventID == "" {
+		fail("includesuperseded requires eventid")
+	}
+	if qp.IncludeSuperseded && qp.IncludeDeleted {
+		fail("includesuperseded and includedeleted are mutually exclusive")
+	}
+	if qp.Limit < 0 || qp.Limit > maxLimit {
+		fail("limit %d outside of [1, %d]", qp.Limit, maxLimit)
+	}
+	if qp.Offset < 0 {
+		fail("offset %d must be at least 1", qp.Offset)
+	}
+	inRange("mindepth", qp.MinDepth, -100, 1000)
+	inRange("maxdepth", qp.MaxDepth, -100, 1000)
+	ordered("mindepth", qp.MinDepth, "maxdepth", qp.MaxDepth, false)
+	ordered("minmagnitude", qp.MinMagnitude, "maxmagnitude", qp.MaxMagnitude, false)
+	oneOf("orderby", string(qp.OrderBy), string(OrderTimeDesc), string(OrderTimeAsc), string(OrderMagnitudeDesc), string(OrderMagnitudeAsc))
+	if qp.TotalResults < 0 {
+		fail("total results %d must not be negative", qp.TotalResults)
+	}
+	if qp.Concurrency < 0 {
+		fail("concurrency %d must not be negative", qp.Concurrency)
+	}
+
+	// Extensions
+	oneOf("alertlevel", string(qp.AlertLevel), string(AlertLevelGreen), string(AlertLevelYellow), string(AlertLevelOrange), string(AlertLevelRed))
+	inRange("mincdi", qp.MinCdi, 0, 12)
+	inRange("maxcdi", qp.MaxCdi, 0, 12)
+	ordered("mincdi", qp.MinCdi, "maxcdi", qp.MaxCdi, false)
+	inRange("mingap", qp.MinGap, 0, 360)
+	inRange("maxgap", qp.MaxGap, 0, 360)
+	ordered("mingap", qp.MinGap, "maxgap", qp.MaxGap, false)
+	inRange("maxmmi", qp.MaxMmi, 0, 12)
+	if qp.MinSig != 0 && qp.MaxSig != 0 && qp.MinSig > qp.MaxSig {
+		fail("minsig %d must be less than maxsig %d", qp.MinSig, qp.MaxSig)
+	}
+	if qp.MinFelt < 0 {
+		fail("minfelt %d must be at least 1", qp.MinFelt)
+	}
+	if qp.NoData != 0 && qp.NoData != http.StatusNoContent && qp.NoData != http.StatusNotFound {
+		fail("nodata %d not one of [204 404]", qp.NoData)
+	}
+	oneOf("reviewstatus", string(qp.ReviewStatus), string(ReviewStatusAll), string(ReviewStatusAutomatic), string(ReviewStatusReviewed))
+
+	// Format Specific
+	oneOf("kmlcolorby", string(qp.KMLColorBy), string(KMLColorByAge), string(KMLColorByDepth))
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+
+}
@@ -1,6 +1,9 @@
 package earthquake
 
 import (
+	"errors"
+	"io/ioutil"
+	"net/http"
 	"testing"
 	"time"
 )
@@ -55,3 +58,103 @@ func TestQueryParameters(t *testing.T) {
 	}
 
 }
+
+func TestQueryParametersKML(t *testing.T) {
+
+	qp := NewQueryParameters()
+
+	qp.Format = FormatKML
+	qp.KMLColorBy = KMLColorByDepth
+	qp.KMLAnimated = true
+
+	const expected = `format=kml&kmlanimated=true&kmlcolorby=depth`
+
+	if out := qp.Encode(); out != expected {
+		t.Errorf("expected: %q\n got: %q", expected, out)
+	}
+
+}
+
+func TestQueryParametersNoData(t *testing.T) {
+
+	qp := NewQueryParameters()
+	qp.NoData = 404
+
+	const expected = `format=geojson&nodata=404`
+
+	if out := qp.Encode(); out != expected {
+		t.Errorf("expected: %q\n got: %q", expected, out)
+	}
+
+}
+
+func TestQueryParametersValidate(t *testing.T) {
+
+	qp := NewQueryParameters()
+	if err := qp.Validate(); err != nil {
+		t.Fatalf("expected defaults to validate, got %v", err)
+	}
+
+	qp.StartTime = time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)
+	qp.EndTime = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	qp.MinLatitude = 10
+	qp.MaxLatitude = 100
+	qp.Latitude = 10
+	qp.MaxRadius = 1
+	qp.MaxRadiusKM = 100
+	qp.Limit = 20001
+	qp.IncludeSuperseded = true
+	qp.IncludeDeleted = true
+	qp.MinSig = 600
+	qp.MaxSig = 500
+	qp.NoData = 500
+	qp.Format = "json"
+
+	err := qp.Validate()
+	var ve ValidationErrors
+	if !errors.As(err, &ve) {
+		t.Fatalf("expected ValidationErrors, got %T %v", err, err)
+	}
+	// starttime, maxlatitude range, maxradius/maxradiuskm, circle, limit,
+	// includesuperseded x2, sig, nodata, format
+	if len(ve) != 10 {
+		t.Errorf("expected 10 violations, got %d: %v", len(ve), err)
+	}
+
+}
+
+func TestValidateBeforeRequest(t *testing.T) {
+
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request %s", r.URL)
+	})
+
+	qp := NewQueryParameters()
+	qp.MinMagnitude = 5
+	qp.MaxMagnitude = 4
+
+	var ve ValidationErrors
+	if _, err := c.GetQuery(qp); !errors.As(err, &ve) {
+		t.Errorf("expected ValidationErrors from GetQuery, got %v", err)
+	}
+	if _, err := c.GetCount(qp); !errors.As(err, &ve) {
+		t.Errorf("expected ValidationErrors from GetCount, got %v", err)
+	}
+
+	// methods overriding the format ignore a stale one
+	c = newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	qp = NewQueryParameters()
+	qp.Format = "json"
+	if _, err := c.GetCount(qp); err != nil {
+		t.Errorf("GetCount: %v", err)
+	}
+	if _, err := c.GetQueryQuakeML(qp); err != nil {
+		t.Errorf("GetQueryQuakeML: %v", err)
+	}
+	if err := c.GetQueryKML(qp, ioutil.Discard); err != nil {
+		t.Errorf("GetQueryKML: %v", err)
+	}
+
+}
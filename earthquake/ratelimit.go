@@ -0,0 +1,88 @@
+package earthquake
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RateLimiter is a token bucket allowing rate requests per second with bursts
+// of up to burst requests.  A single RateLimiter may be shared by many Clients
+// to throttle them together.
+type RateLimiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// a rate <= 0 does not limit at all.
+func NewRateLimiter(rate float64, burst int) *RateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &RateLimiter{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// take a token, waiting until one is available or ctx is done.
+func (l *RateLimiter) Wait(ctx context.Context) error {
+
+	if !(l.rate > 0) {
+		return nil
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.last = now
+	l.tokens--
+	var wait time.Duration
+	if l.tokens < 0 {
+		wait = time.Duration(-l.tokens / l.rate * float64(time.Second))
+	}
+	l.mu.Unlock()
+
+	if wait == 0 {
+		return nil
+	}
+
+	t := time.NewTimer(wait)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		// return the reserved token
+		l.mu.Lock()
+		l.tokens++
+		l.mu.Unlock()
+		return ctx.Err()
+	}
+
+}
+
+// throttle every request made by the client, including each page of a paged
+// query and each retry, with l.
+func WithRateLimiter(l *RateLimiter) Option {
+	return func(c *Client) {
+		c.limiter = l
+	}
+}
+
+// throttle the client to rate requests per second with bursts of burst requests.
+// A rate <= 0 leaves the client unthrottled.
+func WithRateLimit(rate float64, burst int) Option {
+	if !(rate > 0) {
+		return WithRateLimiter(nil)
+	}
+	return WithRateLimiter(NewRateLimiter(rate, burst))
+}
@@ -0,0 +1,89 @@
+package earthquake
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter(t *testing.T) {
+
+	l := NewRateLimiter(100, 2)
+	ctx := context.Background()
+
+	start := time.Now()
+	for i := 0; i < 6; i++ {
+		if err := l.Wait(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 2 burst tokens then 4 more at 10ms each
+	if d := time.Since(start); d < 35*time.Millisecond {
+		t.Errorf("expected at least 35ms, got %v", d)
+	}
+
+}
+
+func TestRateLimiterUnlimited(t *testing.T) {
+
+	for _, rate := range []float64{0, -1, math.NaN()} {
+		l := NewRateLimiter(rate, 1)
+		for i := 0; i < 5; i++ {
+			if err := l.Wait(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if l.tokens != 1 {
+			t.Errorf("rate %v: expected tokens untouched, got %v", rate, l.tokens)
+		}
+		if c := NewClient(WithRateLimit(rate, 1)); c.limiter != nil {
+			t.Errorf("rate %v: expected no limiter", rate)
+		}
+	}
+
+}
+
+func TestRateLimiterContext(t *testing.T) {
+
+	l := NewRateLimiter(0.001, 1)
+	if err := l.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := l.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected %v, got %v", context.DeadlineExceeded, err)
+	}
+
+}
+
+func TestClientRateLimiterShared(t *testing.T) {
+
+	var requests int
+	h := func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte("1.0"))
+	}
+
+	l := NewRateLimiter(0.001, 1)
+	a := newTestClient(t, h, WithRateLimiter(l))
+	b := newTestClient(t, h, WithRateLimiter(l))
+
+	if _, err := a.GetVersion(); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := b.GetVersionContext(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected %v, got %v", context.DeadlineExceeded, err)
+	}
+	if requests != 1 {
+		t.Errorf("expected 1 request, got %d", requests)
+	}
+
+}
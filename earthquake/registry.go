@@ -0,0 +1,106 @@
+package earthquake
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// comma delimited lists are sent with leading and trailing commas, ie: ",us2000ck9q,ci37845255,"
+func splitList(s string) []string {
+	var list []string
+	for _, v := range strings.Split(s, ",") {
+		if v != "" {
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
+// every id the event has been associated with
+func (p *Properties) IDList() []string { return splitList(p.Ids) }
+
+// the network contributors of the event, in the same order as IDList
+func (p *Properties) SourceList() []string { return splitList(p.Sources) }
+
+// the product types associated with the event
+func (p *Properties) TypeList() []string { return splitList(p.Types) }
+
+// EventRegistry maps every id an event has been known by to its current
+// preferred id.  Preferred ids change when the preferred origin switches
+// networks, and separate events may later be associated into one.
+type EventRegistry struct {
+	mu        sync.RWMutex
+	preferred map[string]string
+	aliases   map[string][]string
+}
+
+func NewEventRegistry() *EventRegistry {
+	return &EventRegistry{
+		preferred: make(map[string]string),
+		aliases:   make(map[string][]string),
+	}
+}
+
+// record the ids of f under its preferred id f.ID.  Returns the preferred ids
+// previously registered for any of its ids that are now aliases of f.ID, these
+// are events already stored under another id.
+func (r *EventRegistry) Add(f *Feature) (replaced []string) {
+	return r.add(f.ID, f.Properties.IDList())
+}
+
+func (r *EventRegistry) add(preferred string, ids []string) (replaced []string) {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	all := make(map[string]bool)
+	all[preferred] = true
+	for _, alias := range r.aliases[preferred] {
+		all[alias] = true
+	}
+	for _, id := range ids {
+		all[id] = true
+	}
+
+	merged := make(map[string]bool)
+	for _, id := range append([]string{preferred}, ids...) {
+		prev, exists := r.preferred[id]
+		if !exists || prev == preferred || merged[prev] {
+			continue
+		}
+		merged[prev] = true
+		for _, alias := range r.aliases[prev] {
+			all[alias] = true
+		}
+		delete(r.aliases, prev)
+		replaced = append(replaced, prev)
+	}
+
+	aliases := make([]string, 0, len(all))
+	for id := range all {
+		r.preferred[id] = preferred
+		aliases = append(aliases, id)
+	}
+	sort.Strings(aliases)
+	r.aliases[preferred] = aliases
+
+	return replaced
+
+}
+
+// the current preferred id of any id the event has been known by
+func (r *EventRegistry) Resolve(id string) (string, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	preferred, exists := r.preferred[id]
+	return preferred, exists
+}
+
+// all ids of the event known by id, including the preferred id
+func (r *EventRegistry) Aliases(id string) []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	aliases := r.aliases[r.preferred[id]]
+	return append([]string(nil), aliases...)
+}
@@ -0,0 +1,75 @@
+package earthquake
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+)
+
+func TestPropertiesLists(t *testing.T) {
+
+	var f Feature
+	if err := json.Unmarshal([]byte(testFeature), &f); err != nil {
+		t.Fatal(err)
+	}
+	f.Properties.Ids = ",ci37845255,us2000ck9q,"
+	f.Properties.Sources = ",ci,us,"
+
+	if ids := f.Properties.IDList(); fmt.Sprint(ids) != "[ci37845255 us2000ck9q]" {
+		t.Errorf("unexpected ids %q", ids)
+	}
+	if sources := f.Properties.SourceList(); fmt.Sprint(sources) != "[ci us]" {
+		t.Errorf("unexpected sources %q", sources)
+	}
+	if types := f.Properties.TypeList(); len(types) != 4 || types[0] != "geoserve" {
+		t.Errorf("unexpected types %q", types)
+	}
+	if ids := (&Properties{}).IDList(); len(ids) != 0 {
+		t.Errorf("expected no ids, got %q", ids)
+	}
+
+}
+
+func TestEventRegistry(t *testing.T) {
+
+	feature := func(id, ids string) *Feature {
+		f := &Feature{ID: id}
+		f.Properties.Ids = ids
+		return f
+	}
+
+	r := NewEventRegistry()
+
+	if replaced := r.Add(feature("ci37845255", ",ci37845255,")); len(replaced) != 0 {
+		t.Errorf("expected nothing replaced, got %q", replaced)
+	}
+	if replaced := r.Add(feature("us2000ck9q", ",us2000ck9q,")); len(replaced) != 0 {
+		t.Errorf("expected nothing replaced, got %q", replaced)
+	}
+
+	// the events are associated with the us origin preferred
+	if replaced := r.Add(feature("us2000ck9q", ",ci37845255,us2000ck9q,")); fmt.Sprint(replaced) != "[ci37845255]" {
+		t.Errorf("expected ci37845255 replaced, got %q", replaced)
+	}
+	if id, ok := r.Resolve("ci37845255"); !ok || id != "us2000ck9q" {
+		t.Errorf("expected us2000ck9q, got %q %v", id, ok)
+	}
+
+	// the preferred origin switches networks
+	if replaced := r.Add(feature("at00p1qk2x", ",at00p1qk2x,ci37845255,us2000ck9q,")); fmt.Sprint(replaced) != "[us2000ck9q]" {
+		t.Errorf("expected us2000ck9q replaced, got %q", replaced)
+	}
+	for _, id := range []string{"at00p1qk2x", "ci37845255", "us2000ck9q"} {
+		if preferred, _ := r.Resolve(id); preferred != "at00p1qk2x" {
+			t.Errorf("%s: expected at00p1qk2x, got %q", id, preferred)
+		}
+	}
+	if aliases := r.Aliases("us2000ck9q"); fmt.Sprint(aliases) != "[at00p1qk2x ci37845255 us2000ck9q]" {
+		t.Errorf("unexpected aliases %q", aliases)
+	}
+
+	if _, ok := r.Resolve("nc72947001"); ok {
+		t.Error("expected unknown id")
+	}
+
+}
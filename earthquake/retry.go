@@ -0,0 +1,158 @@
+package earthquake
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type (
+	// RetryPolicy retries idempotent requests that fail with a transport error
+	// or a 429, 500, 502, 503 or 504 response.  The wait between attempts grows
+	// exponentially from MinBackoff to MaxBackoff with jitter, unless the
+	// response carries a Retry-After header which is honored instead.
+	RetryPolicy struct {
+		// total attempts including the first, values <= 1 disable retries
+		MaxAttempts int
+		// defaults to 500ms
+		MinBackoff time.Duration
+		// defaults to 30s
+		MaxBackoff time.Duration
+		// called before waiting for each retry
+		OnRetry func(RetryEvent)
+	}
+
+	RetryEvent struct {
+		Request *http.Request
+		// the attempt that failed, starting at 1
+		Attempt int
+		// the failed response status, 0 on transport errors
+		StatusCode int
+		Err        error
+		Wait       time.Duration
+	}
+)
+
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 4,
+	MinBackoff:  500 * time.Millisecond,
+	MaxBackoff:  30 * time.Second,
+}
+
+// retry failed requests according to p.  Retries happen within a single call to
+// the underlying http.Client, so a timeout set by WithTimeout bounds the whole
+// sequence of attempts and backoffs, not each attempt.
+func WithRetry(p RetryPolicy) Option {
+	return func(c *Client) {
+		c.retry = p
+	}
+}
+
+func retryableStatus(code int) bool {
+	switch code {
+	case http.StatusTooManyRequests,
+		http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// the wait before attempt+1, from the Retry-After header when present.  Either
+// way the wait is capped at MaxBackoff.
+func (p RetryPolicy) backoff(attempt int, resp *http.Response) time.Duration {
+
+	minWait, maxWait := p.MinBackoff, p.MaxBackoff
+	if minWait <= 0 {
+		minWait = DefaultRetryPolicy.MinBackoff
+	}
+	if maxWait <= 0 {
+		maxWait = DefaultRetryPolicy.MaxBackoff
+	}
+
+	if resp != nil {
+		if ra := resp.Header.Get("Retry-After"); ra != "" {
+			d, ok := time.Duration(0), false
+			if secs, err := strconv.Atoi(ra); err == nil && secs >= 0 {
+				d, ok = time.Duration(secs)*time.Second, true
+			} else if t, err := http.ParseTime(ra); err == nil {
+				d, ok = time.Until(t), true
+			}
+			if ok {
+				if d < 0 {
+					d = 0
+				}
+				if d > maxWait {
+					d = maxWait
+				}
+				return d
+			}
+		}
+	}
+
+	d := minWait
+	for i := 1; i < attempt && d < maxWait; i++ {
+		d *= 2
+	}
+	if d > maxWait {
+		d = maxWait
+	}
+
+	// equal jitter: half fixed, half random
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+
+}
+
+// perform req with the retry policy
+func (p RetryPolicy) roundTrip(req *http.Request, rt func(*http.Request) (*http.Response, error)) (*http.Response, error) {
+
+	idempotent := (req.Method == http.MethodGet || req.Method == http.MethodHead) && req.Body == nil
+
+	for attempt := 1; ; attempt++ {
+
+		resp, err := rt(req)
+
+		if attempt >= p.MaxAttempts || !idempotent {
+			return resp, err
+		}
+		if err != nil {
+			if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+				return nil, err
+			}
+		} else if !retryableStatus(resp.StatusCode) {
+			return resp, nil
+		}
+
+		wait := p.backoff(attempt, resp)
+
+		if p.OnRetry != nil {
+			ev := RetryEvent{Request: req, Attempt: attempt, Err: err, Wait: wait}
+			if resp != nil {
+				ev.StatusCode = resp.StatusCode
+			}
+			p.OnRetry(ev)
+		}
+
+		if resp != nil {
+			io.Copy(ioutil.Discard, io.LimitReader(resp.Body, 1<<16))
+			resp.Body.Close()
+		}
+
+		t := time.NewTimer(wait)
+		select {
+		case <-t.C:
+		case <-req.Context().Done():
+			t.Stop()
+			return nil, req.Context().Err()
+		}
+
+	}
+
+}
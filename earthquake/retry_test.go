@@ -0,0 +1,112 @@
+package earthquake
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestRetry(t *testing.T) {
+
+	var (
+		requests int
+		events   []RetryEvent
+	)
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch requests {
+		case 1:
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case 2:
+			w.WriteHeader(http.StatusBadGateway)
+		default:
+			w.Write([]byte("1.0"))
+		}
+	}, WithRetry(RetryPolicy{
+		MaxAttempts: 3,
+		MinBackoff:  time.Millisecond,
+		MaxBackoff:  2 * time.Millisecond,
+		OnRetry: func(ev RetryEvent) {
+			events = append(events, ev)
+		},
+	}))
+
+	if _, err := c.GetVersion(); err != nil {
+		t.Fatal(err)
+	}
+	if requests != 3 {
+		t.Errorf("expected 3 requests, got %d", requests)
+	}
+	if len(events) != 2 {
+		t.Fatalf("expected 2 retry events, got %d", len(events))
+	}
+	if events[0].StatusCode != http.StatusServiceUnavailable || events[0].Wait != 0 {
+		t.Errorf("unexpected first event %+v", events[0])
+	}
+	if events[1].Attempt != 2 || events[1].Wait > 2*time.Millisecond {
+		t.Errorf("unexpected second event %+v", events[1])
+	}
+
+}
+
+func TestRetryGivesUp(t *testing.T) {
+
+	var requests int
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path == "/fdsnws/event/1/catalogs" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}, WithRetry(RetryPolicy{MaxAttempts: 2, MinBackoff: time.Millisecond}))
+
+	if _, err := c.GetVersion(); !IsRateLimited(err) {
+		t.Errorf("expected 503, got %v", err)
+	}
+	if requests != 2 {
+		t.Errorf("expected 2 requests, got %d", requests)
+	}
+
+	requests = 0
+	if _, err := c.GetCatalogs(); !IsBadRequest(err) {
+		t.Errorf("expected 400, got %v", err)
+	}
+	if requests != 1 {
+		t.Errorf("expected 1 request, got %d", requests)
+	}
+
+}
+
+func TestRetryBackoff(t *testing.T) {
+
+	p := RetryPolicy{MinBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+
+	for attempt, want := range map[int]time.Duration{
+		1: 100 * time.Millisecond,
+		2: 200 * time.Millisecond,
+		4: 800 * time.Millisecond,
+		8: time.Second,
+	} {
+		if d := p.backoff(attempt, nil); d < want/2 || d > want {
+			t.Errorf("attempt %d: expected [%v, %v], got %v", attempt, want/2, want, d)
+		}
+	}
+
+	resp := &http.Response{Header: http.Header{"Retry-After": {"7"}}}
+	if d := (RetryPolicy{MaxBackoff: 10 * time.Second}).backoff(1, resp); d != 7*time.Second {
+		t.Errorf("expected %v, got %v", 7*time.Second, d)
+	}
+
+	// Retry-After is capped at MaxBackoff
+	resp.Header.Set("Retry-After", "3600")
+	if d := p.backoff(1, resp); d != time.Second {
+		t.Errorf("expected %v, got %v", time.Second, d)
+	}
+	resp.Header.Set("Retry-After", time.Now().Add(time.Hour).UTC().Format(http.TimeFormat))
+	if d := p.backoff(1, resp); d != time.Second {
+		t.Errorf("expected %v, got %v", time.Second, d)
+	}
+
+}
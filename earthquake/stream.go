@@ -0,0 +1,190 @@
+package earthquake
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+)
+
+// FeatureIterator decodes query results one feature at a time so memory use
+// is independent of the page size.
+//
+//	it, err := client.GetQueryStream(qp)
+//	if err != nil {
+//		return err
+//	}
+//	defer it.Close()
+//	for it.Next() {
+//		f := it.Feature()
+//		...
+//	}
+//	return it.Err()
+type FeatureIterator struct {
+	// populated as they are encountered in the response, in practice metadata
+	// precedes the features and bbox follows them.
+	Metadata Metadata
+	Bbox     []float64
+
+	body io.ReadCloser
+	dec  *json.Decoder
+	csv  *csvReader
+
+	inFeatures bool
+	f          *Feature
+	err        error
+}
+
+// submit a data request and iterate over the results as they are decoded.
+// The caller must Close the iterator.
+func (c *Client) GetQueryStream(qp *queryParameters) (*FeatureIterator, error) {
+	return c.GetQueryStreamContext(context.Background(), qp)
+}
+
+func (c *Client) GetQueryStreamContext(ctx context.Context, qp *queryParameters) (*FeatureIterator, error) {
+	if err := qp.Validate(); err != nil {
+		return nil, err
+	}
+	switch qp.Format {
+	case FormatGeoJSON, FormatCSV:
+	default:
+		return nil, fmt.Errorf("unsupported query format %q", qp.Format)
+	}
+	resp, err := c.get(ctx, "/query?"+qp.Encode())
+	if qp.isNoData(err) {
+		return &FeatureIterator{body: ioutil.NopCloser(strings.NewReader("")), err: io.EOF}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return newFeatureIterator(resp.Body, qp.Format)
+}
+
+func newFeatureIterator(body io.ReadCloser, format Format) (*FeatureIterator, error) {
+
+	it := &FeatureIterator{body: body}
+
+	if format == FormatCSV {
+		cr, err := newCSVReader(body)
+		if err != nil {
+			body.Close()
+			return nil, err
+		}
+		it.csv = cr
+		return it, nil
+	}
+
+	it.dec = json.NewDecoder(body)
+	if err := it.expectDelim('{'); err != nil {
+		body.Close()
+		return nil, err
+	}
+
+	return it, nil
+
+}
+
+func (it *FeatureIterator) expectDelim(d json.Delim) error {
+	tok, err := it.dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok != d {
+		return fmt.Errorf("expected %q, got %v", d, tok)
+	}
+	return nil
+}
+
+// advance to the next feature of the top level object, decoding any other
+// members along the way.  Returns io.EOF at the end of the object.
+func (it *FeatureIterator) advance() error {
+
+	for {
+		if it.inFeatures {
+			if it.dec.More() {
+				return nil
+			}
+			if err := it.expectDelim(']'); err != nil {
+				return err
+			}
+			it.inFeatures = false
+		}
+
+		if !it.dec.More() {
+			if err := it.expectDelim('}'); err != nil {
+				return err
+			}
+			return io.EOF
+		}
+
+		tok, err := it.dec.Token()
+		if err != nil {
+			return err
+		}
+		key, _ := tok.(string)
+
+		switch key {
+		case "features":
+			if err := it.expectDelim('['); err != nil {
+				return err
+			}
+			it.inFeatures = true
+		case "metadata":
+			err = it.dec.Decode(&it.Metadata)
+		case "bbox":
+			err = it.dec.Decode(&it.Bbox)
+		default:
+			var skip json.RawMessage
+			err = it.dec.Decode(&skip)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+}
+
+// decode the next feature, returning false when done or on error.
+func (it *FeatureIterator) Next() bool {
+
+	if it.err != nil {
+		return false
+	}
+
+	if it.csv != nil {
+		it.f, it.err = it.csv.Read()
+		return it.err == nil
+	}
+
+	if it.err = it.advance(); it.err != nil {
+		return false
+	}
+
+	var f Feature
+	if it.err = it.dec.Decode(&f); it.err != nil {
+		return false
+	}
+	it.f = &f
+
+	return true
+
+}
+
+// the feature decoded by the last call to Next
+func (it *FeatureIterator) Feature() *Feature {
+	return it.f
+}
+
+// the first error encountered by the iterator, nil at the end of the results
+func (it *FeatureIterator) Err() error {
+	if it.err == io.EOF {
+		return nil
+	}
+	return it.err
+}
+
+func (it *FeatureIterator) Close() error {
+	return it.body.Close()
+}
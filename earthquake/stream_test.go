@@ -0,0 +1,87 @@
+package earthquake
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestFeatureIterator(t *testing.T) {
+
+	const body = `{"type":"FeatureCollection","metadata":{"count":2,"status":200},"features":[` + testFeature + `,{"type":"Feature","id":"b","properties":{},"geometry":null}],"bbox":[1,2,3,4,5,6]}`
+
+	it, err := newFeatureIterator(ioutil.NopCloser(strings.NewReader(body)), FormatGeoJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer it.Close()
+
+	var ids []string
+	for it.Next() {
+		ids = append(ids, it.Feature().ID)
+	}
+	if err := it.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(ids) != 2 || ids[0] != "nc72947001" || ids[1] != "b" {
+		t.Errorf("unexpected ids %q", ids)
+	}
+	if it.Metadata.Count != 2 {
+		t.Errorf("expected metadata count 2, got %d", it.Metadata.Count)
+	}
+	if len(it.Bbox) != 6 {
+		t.Errorf("expected bbox, got %v", it.Bbox)
+	}
+
+}
+
+func TestFeatureIteratorMalformed(t *testing.T) {
+
+	it, err := newFeatureIterator(ioutil.NopCloser(strings.NewReader(`{"features":[{"id":"a"},{"id":`)), FormatGeoJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer it.Close()
+
+	var n int
+	for it.Next() {
+		n++
+	}
+	if n != 1 {
+		t.Errorf("expected 1 feature, got %d", n)
+	}
+	if it.Err() == nil {
+		t.Error("expected error, got none")
+	}
+
+}
+
+func TestGetQueryStreamCSV(t *testing.T) {
+
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(testCSV))
+	})
+
+	qp := NewQueryParameters()
+	qp.Format = FormatCSV
+
+	it, err := c.GetQueryStream(qp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer it.Close()
+
+	var n int
+	for it.Next() {
+		n++
+	}
+	if err := it.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Errorf("expected 2 features, got %d", n)
+	}
+
+}
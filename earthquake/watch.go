@@ -0,0 +1,281 @@
+package earthquake
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+type (
+	WatchEventType string
+
+	WatchEvent struct {
+		Type    WatchEventType
+		Feature *Feature
+	}
+
+	// called for each new, updated or deleted event.  Returning an error stops
+	// the watch.
+	WatchHandler func(WatchEvent) error
+
+	// Checkpoint is the progress of a watch, saved after every poll so a
+	// restarted watch resumes where it left off.
+	Checkpoint struct {
+		// the latest updated time seen
+		UpdatedAfter time.Time `json:"updatedAfter"`
+		// event id to the updated time in milliseconds of the version last handled,
+		// for events updated within the seen retention of UpdatedAfter
+		Seen map[string]int64 `json:"seen"`
+	}
+
+	CheckpointStore interface {
+		// returns nil when there is no checkpoint
+		Load(ctx context.Context) (*Checkpoint, error)
+		Save(ctx context.Context, cp *Checkpoint) error
+	}
+
+	WatchOption func(*watchConfig)
+
+	watchConfig struct {
+		interval  time.Duration
+		retention time.Duration
+		store     CheckpointStore
+	}
+)
+
+const (
+	WatchEventNew     WatchEventType = "new"
+	WatchEventUpdated WatchEventType = "updated"
+	WatchEventDeleted WatchEventType = "deleted"
+)
+
+const (
+	DefaultWatchInterval = time.Minute
+	DefaultSeenRetention = 7 * 24 * time.Hour
+)
+
+// poll every d instead of DefaultWatchInterval.  A d <= 0 keeps the default
+// rather than polling the service in a tight loop.
+func WithWatchInterval(d time.Duration) WatchOption {
+	return func(wc *watchConfig) {
+		if d <= 0 {
+			d = DefaultWatchInterval
+		}
+		wc.interval = d
+	}
+}
+
+// remember handled events for d before the latest updated time seen instead of
+// DefaultSeenRetention.  Older entries are dropped from the checkpoint to bound
+// its size, so a later update to such an event is reported as new.
+func WithSeenRetention(d time.Duration) WatchOption {
+	return func(wc *watchConfig) {
+		wc.retention = d
+	}
+}
+
+// load and save the watch progress with s, by default it is kept in memory.
+func WithCheckpointStore(s CheckpointStore) WatchOption {
+	return func(wc *watchConfig) {
+		wc.store = s
+	}
+}
+
+// follow the catalog, repeatedly querying with qp for events updated after the
+// latest updated time seen and calling h for each new, updated or deleted event.
+// Events are deduplicated by id and updated time.  Note the service limits
+// results to events after qp.StartTime, NOW - 30 days by default.
+// Watch includes deleted events so qp may not set IncludeSuperseded.
+// Watch runs until ctx is done or h returns an error.
+func (c *Client) Watch(ctx context.Context, qp *queryParameters, h WatchHandler, opts ...WatchOption) error {
+
+	if qp.IncludeSuperseded {
+		return errors.New("watch does not support includesuperseded")
+	}
+
+	wc := &watchConfig{
+		interval:  DefaultWatchInterval,
+		retention: DefaultSeenRetention,
+		store:     &MemoryCheckpointStore{},
+	}
+	for _, opt := range opts {
+		opt(wc)
+	}
+
+	cp, err := wc.store.Load(ctx)
+	if err != nil {
+		return err
+	}
+	if cp == nil {
+		cp = &Checkpoint{UpdatedAfter: qp.UpdatedAfter}
+	}
+	if cp.Seen == nil {
+		cp.Seen = make(map[string]int64)
+	}
+
+	for {
+
+		if err := c.watchPoll(ctx, qp, cp, h, wc.retention); err != nil {
+			// keep the progress made before the failure
+			if serr := wc.store.Save(ctx, cp); serr != nil && ctx.Err() == nil {
+				return serr
+			}
+			return err
+		}
+		if err := wc.store.Save(ctx, cp); err != nil {
+			return err
+		}
+
+		t := time.NewTimer(wc.interval)
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		}
+
+	}
+
+}
+
+func (c *Client) watchPoll(ctx context.Context, qp *queryParameters, cp *Checkpoint, h WatchHandler, retention time.Duration) error {
+
+	wqp := *qp
+	wqp.UpdatedAfter = cp.UpdatedAfter
+	wqp.IncludeDeleted = true
+	wqp.Offset = 0
+	wqp.TotalResults = math.MaxInt32
+
+	latest := cp.UpdatedAfter
+
+	err := c.GetQueryPagedContext(ctx, &wqp, func(resp *GetQueryResponse) error {
+		for i := range resp.Features {
+			f := &resp.Features[i]
+			updated := f.Properties.Updated.UnixNano() / int64(time.Millisecond)
+
+			prev, seen := cp.Seen[f.ID]
+			if seen && updated <= prev {
+				continue
+			}
+
+			ev := WatchEvent{Type: WatchEventNew, Feature: f}
+			switch {
+			case f.Properties.Status == "deleted":
+				ev.Type = WatchEventDeleted
+			case seen:
+				ev.Type = WatchEventUpdated
+			}
+			if err := h(ev); err != nil {
+				return err
+			}
+
+			cp.Seen[f.ID] = updated
+			if f.Properties.Updated.After(latest) {
+				latest = f.Properties.Updated.Time
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// only advance once every event has been handled, results are not
+	// ordered by updated time
+	cp.UpdatedAfter = latest
+
+	// only events updated near UpdatedAfter can be returned again unchanged
+	cutoff := latest.Add(-retention).UnixNano() / int64(time.Millisecond)
+	for id, updated := range cp.Seen {
+		if updated < cutoff {
+			delete(cp.Seen, id)
+		}
+	}
+
+	return nil
+
+}
+
+// MemoryCheckpointStore keeps the checkpoint in memory.
+type MemoryCheckpointStore struct {
+	mu sync.Mutex
+	cp *Checkpoint
+}
+
+func (m *MemoryCheckpointStore) Load(ctx context.Context) (*Checkpoint, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.cp == nil {
+		return nil, nil
+	}
+	return m.cp.clone(), nil
+}
+
+func (m *MemoryCheckpointStore) Save(ctx context.Context, cp *Checkpoint) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.cp = cp.clone()
+	return nil
+}
+
+func (cp *Checkpoint) clone() *Checkpoint {
+	c := &Checkpoint{
+		UpdatedAfter: cp.UpdatedAfter,
+		Seen:         make(map[string]int64, len(cp.Seen)),
+	}
+	for id, updated := range cp.Seen {
+		c.Seen[id] = updated
+	}
+	return c
+}
+
+// FileCheckpointStore keeps the checkpoint in a json file at Path.
+type FileCheckpointStore struct {
+	Path string
+}
+
+func (f *FileCheckpointStore) Load(ctx context.Context) (*Checkpoint, error) {
+	data, err := ioutil.ReadFile(f.Path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var cp Checkpoint
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return nil, err
+	}
+	return &cp, nil
+}
+
+func (f *FileCheckpointStore) Save(ctx context.Context, cp *Checkpoint) error {
+
+	data, err := json.Marshal(cp)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(f.Path), filepath.Base(f.Path)+".tmp-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(data)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), f.Path)
+
+}
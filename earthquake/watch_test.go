@@ -0,0 +1,198 @@
+package earthquake
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+type watchTestEvent struct {
+	id      string
+	updated int64
+	status  string
+}
+
+// serves the events updated after the updatedafter parameter, the catalog
+// advances to the next set of events on each query
+func watchTestHandler(t *testing.T, catalog [][]watchTestEvent) http.HandlerFunc {
+	var (
+		mu   sync.Mutex
+		poll int
+	)
+	return func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		var after time.Time
+		if s := r.URL.Query().Get("updatedafter"); s != "" {
+			var err error
+			if after, err = time.Parse(time.RFC3339Nano, s); err != nil {
+				t.Error(err)
+			}
+		}
+		if r.URL.Query().Get("includedeleted") != "true" {
+			t.Error("expected includedeleted")
+		}
+
+		var events []watchTestEvent
+		if poll < len(catalog) {
+			for _, e := range catalog[poll] {
+				if time.Unix(0, e.updated*int64(time.Millisecond)).Before(after) {
+					continue
+				}
+				events = append(events, e)
+			}
+		}
+
+		switch r.URL.Path {
+		case "/fdsnws/event/1/count":
+			fmt.Fprintf(w, `{"count":%d,"maxAllowed":20000}`, len(events))
+		case "/fdsnws/event/1/query":
+			poll++
+			var v GetQueryResponse
+			for _, e := range events {
+				var f Feature
+				f.ID = e.id
+				f.Properties.Updated = UnixEpoch{time.Unix(0, e.updated*int64(time.Millisecond))}
+				f.Properties.Status = e.status
+				v.Features = append(v.Features, f)
+			}
+			json.NewEncoder(w).Encode(v)
+		}
+	}
+}
+
+func TestWatch(t *testing.T) {
+
+	c := newTestClient(t, watchTestHandler(t, [][]watchTestEvent{
+		{{"a", 1000, "reviewed"}, {"b", 1000, "automatic"}},
+		{{"a", 1000, "reviewed"}, {"b", 2000, "reviewed"}, {"c", 2000, "deleted"}},
+		{{"b", 2000, "reviewed"}, {"c", 2000, "deleted"}},
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	store := &FileCheckpointStore{Path: filepath.Join(t.TempDir(), "checkpoint.json")}
+
+	var got []string
+	err := c.Watch(ctx, NewQueryParameters(), func(ev WatchEvent) error {
+		got = append(got, fmt.Sprintf("%s %s", ev.Type, ev.Feature.ID))
+		if len(got) == 4 {
+			cancel()
+		}
+		return nil
+	}, WithWatchInterval(time.Millisecond), WithCheckpointStore(store))
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected %v, got %v", context.Canceled, err)
+	}
+
+	expected := []string{"new a", "new b", "updated b", "deleted c"}
+	if fmt.Sprint(got) != fmt.Sprint(expected) {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+
+	cp, err := store.Load(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cp == nil || cp.Seen["b"] != 2000 || len(cp.Seen) != 3 {
+		t.Errorf("unexpected checkpoint %+v", cp)
+	}
+
+}
+
+func TestWatchHandlerError(t *testing.T) {
+
+	c := newTestClient(t, watchTestHandler(t, [][]watchTestEvent{
+		{{"a", 1000, "reviewed"}, {"b", 1000, "reviewed"}},
+	}))
+
+	store := &MemoryCheckpointStore{}
+	stop := errors.New("stop")
+
+	err := c.Watch(context.Background(), NewQueryParameters(), func(ev WatchEvent) error {
+		if ev.Feature.ID == "b" {
+			return stop
+		}
+		return nil
+	}, WithCheckpointStore(store))
+	if err != stop {
+		t.Errorf("expected %v, got %v", stop, err)
+	}
+
+	cp, _ := store.Load(context.Background())
+	if cp == nil || len(cp.Seen) != 1 || cp.Seen["a"] != 1000 || !cp.UpdatedAfter.IsZero() {
+		t.Errorf("unexpected checkpoint %+v", cp)
+	}
+
+}
+
+func TestWatchSeenRetention(t *testing.T) {
+
+	c := newTestClient(t, watchTestHandler(t, [][]watchTestEvent{
+		{{"a", 1000, "reviewed"}, {"b", 1000, "automatic"}},
+		{{"a", 1000, "reviewed"}, {"b", 2000, "reviewed"}, {"c", 2000, "deleted"}},
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	store := &MemoryCheckpointStore{}
+
+	var handled int
+	err := c.Watch(ctx, NewQueryParameters(), func(ev WatchEvent) error {
+		if handled++; handled == 4 {
+			cancel()
+		}
+		return nil
+	}, WithWatchInterval(time.Millisecond), WithCheckpointStore(store), WithSeenRetention(500*time.Millisecond))
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected %v, got %v", context.Canceled, err)
+	}
+
+	// a was last updated more than 500ms before the latest update and is dropped
+	cp, _ := store.Load(context.Background())
+	if cp == nil || len(cp.Seen) != 2 || cp.Seen["b"] != 2000 || cp.Seen["c"] != 2000 {
+		t.Errorf("unexpected checkpoint %+v", cp)
+	}
+
+}
+
+func TestWatchIncludeSuperseded(t *testing.T) {
+
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request %s", r.URL)
+	})
+
+	qp := NewQueryParameters()
+	qp.EventID = "us2000ck9q"
+	qp.IncludeSuperseded = true
+
+	if err := c.Watch(context.Background(), qp, func(WatchEvent) error { return nil }); err == nil {
+		t.Error("expected error, got none")
+	}
+
+}
+
+func TestWithWatchInterval(t *testing.T) {
+
+	for d, expected := range map[time.Duration]time.Duration{
+		time.Second:  time.Second,
+		0:            DefaultWatchInterval,
+		-time.Second: DefaultWatchInterval,
+	} {
+		wc := &watchConfig{}
+		WithWatchInterval(d)(wc)
+		if wc.interval != expected {
+			t.Errorf("%v: expected %v, got %v", d, expected, wc.interval)
+		}
+	}
+
+}
@@ -0,0 +1,122 @@
+package earthquake
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// the service limits queries to 20000 events
+const maxLimit = 20000
+
+// TimeWindow is a slice of a query's time range holding Count events.
+type TimeWindow struct {
+	StartTime time.Time
+	EndTime   time.Time
+	Count     int
+}
+
+// split the time range of qp into consecutive windows that each hold no more
+// than the service's maximum number of events.  Windows are found by counting
+// and recursively bisecting the range; empty windows are omitted.  A zero
+// StartTime or EndTime uses the service default of NOW - 30 days and NOW.
+func (c *Client) PlanTimeWindows(qp *queryParameters) ([]TimeWindow, error) {
+	return c.PlanTimeWindowsContext(context.Background(), qp)
+}
+
+func (c *Client) PlanTimeWindowsContext(ctx context.Context, qp *queryParameters) ([]TimeWindow, error) {
+
+	wqp := *qp
+	wqp.Limit = 0
+	wqp.Offset = 0
+
+	end := wqp.EndTime
+	if end.IsZero() {
+		end = time.Now()
+	}
+	start := wqp.StartTime
+	if start.IsZero() {
+		start = end.AddDate(0, 0, -30)
+	}
+
+	// event times have millisecond precision, so align the range to millisecond
+	// boundaries up front, rounding start up so no earlier event is included.
+	end = end.Truncate(time.Millisecond)
+	if t := start.Truncate(time.Millisecond); t.Before(start) {
+		start = t.Add(time.Millisecond)
+	}
+
+	var (
+		windows []TimeWindow
+		plan    func(start, end time.Time) error
+	)
+	plan = func(start, end time.Time) error {
+
+		wqp.StartTime, wqp.EndTime = start, end
+
+		cresp, err := c.GetCountContext(ctx, &wqp)
+		if err != nil {
+			return err
+		}
+
+		maxPerWindow := cresp.MaxAllowed
+		if maxPerWindow == 0 {
+			maxPerWindow = maxLimit
+		}
+
+		switch {
+		case cresp.Count == 0:
+			return nil
+		case cresp.Count <= maxPerWindow:
+			windows = append(windows, TimeWindow{StartTime: start, EndTime: end, Count: cresp.Count})
+			return nil
+		}
+
+		// splitting on a millisecond boundary and starting the next window 1ms
+		// later loses nothing.
+		mid := start.Add(end.Sub(start) / 2).Truncate(time.Millisecond)
+		if !mid.Before(end) {
+			return fmt.Errorf("cannot split %s - %s holding %d events below %d", start.Format(time.RFC3339Nano), end.Format(time.RFC3339Nano), cresp.Count, maxPerWindow)
+		}
+		if err := plan(start, mid); err != nil {
+			return err
+		}
+		return plan(mid.Add(time.Millisecond), end)
+
+	}
+
+	if err := plan(start, end); err != nil {
+		return nil, err
+	}
+
+	return windows, nil
+
+}
+
+// run a query over any time range regardless of the service's event limit by
+// planning time windows with PlanTimeWindows and paging through each of them in order.
+// TotalResults is ignored, every matching event is retrieved.
+func (c *Client) GetQueryWindowed(qp *queryParameters, f func(*GetQueryResponse) error) error {
+	return c.GetQueryWindowedContext(context.Background(), qp, f)
+}
+
+func (c *Client) GetQueryWindowedContext(ctx context.Context, qp *queryParameters, f func(*GetQueryResponse) error) error {
+
+	windows, err := c.PlanTimeWindowsContext(ctx, qp)
+	if err != nil {
+		return err
+	}
+
+	for _, w := range windows {
+		wqp := *qp
+		wqp.StartTime = w.StartTime
+		wqp.EndTime = w.EndTime
+		wqp.TotalResults = w.Count
+		if err := c.GetQueryPagedContext(ctx, &wqp, f); err != nil {
+			return err
+		}
+	}
+
+	return nil
+
+}
@@ -0,0 +1,144 @@
+package earthquake
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// serves count and query requests over a fixed set of event times
+func windowTestHandler(t *testing.T, times []time.Time, maxAllowed int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		q := r.URL.Query()
+		start, err := time.Parse(time.RFC3339Nano, q.Get("starttime"))
+		if err != nil {
+			t.Error(err)
+		}
+		end, err := time.Parse(time.RFC3339Nano, q.Get("endtime"))
+		if err != nil {
+			t.Error(err)
+		}
+
+		var matched []time.Time
+		for _, tm := range times {
+			if !tm.Before(start) && !tm.After(end) {
+				matched = append(matched, tm)
+			}
+		}
+
+		switch r.URL.Path {
+		case "/fdsnws/event/1/count":
+			fmt.Fprintf(w, `{"count":%d,"maxAllowed":%d}`, len(matched), maxAllowed)
+		case "/fdsnws/event/1/query":
+			limit, _ := strconv.Atoi(q.Get("limit"))
+			if limit > maxAllowed {
+				http.Error(w, "limit too large", http.StatusBadRequest)
+				return
+			}
+			offset, _ := strconv.Atoi(q.Get("offset"))
+			var v GetQueryResponse
+			for i := offset - 1; i < len(matched) && i < offset-1+limit; i++ {
+				var f Feature
+				f.ID = matched[i].Format(time.RFC3339Nano)
+				f.Properties.Time = UnixEpoch{matched[i]}
+				v.Features = append(v.Features, f)
+			}
+			json.NewEncoder(w).Encode(v)
+		}
+
+	}
+}
+
+func TestGetQueryWindowed(t *testing.T) {
+
+	base := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	var times []time.Time
+	for i := 0; i < 50; i++ {
+		times = append(times, base.Add(time.Duration(i*i)*time.Minute+time.Duration(i)*time.Millisecond))
+	}
+
+	c := newTestClient(t, windowTestHandler(t, times, 4))
+
+	qp := NewQueryParameters()
+	qp.StartTime = base
+	qp.EndTime = base.Add(72 * time.Hour)
+
+	windows, err := c.PlanTimeWindows(qp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var total int
+	for _, w := range windows {
+		if w.Count > 4 {
+			t.Errorf("window %v - %v exceeds max with %d", w.StartTime, w.EndTime, w.Count)
+		}
+		total += w.Count
+	}
+	if total != len(times) {
+		t.Errorf("expected %d planned events, got %d", len(times), total)
+	}
+
+	seen := make(map[string]int)
+	err = c.GetQueryWindowed(qp, func(resp *GetQueryResponse) error {
+		for _, f := range resp.Features {
+			seen[f.ID]++
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(seen) != len(times) {
+		t.Errorf("expected %d events, got %d", len(times), len(seen))
+	}
+	for id, n := range seen {
+		if n != 1 {
+			t.Errorf("expected %s once, got %d", id, n)
+		}
+	}
+
+}
+
+func TestPlanTimeWindowsUnsplittable(t *testing.T) {
+
+	tm := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := newTestClient(t, windowTestHandler(t, []time.Time{tm, tm, tm}, 2))
+
+	qp := NewQueryParameters()
+	qp.StartTime = tm.Add(-time.Hour)
+	qp.EndTime = tm.Add(time.Hour)
+
+	if _, err := c.PlanTimeWindows(qp); err == nil {
+		t.Error("expected error, got none")
+	}
+
+}
+
+func TestPlanTimeWindowsSubMillisecond(t *testing.T) {
+
+	tm := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	times := []time.Time{tm.Add(time.Millisecond), tm.Add(2 * time.Millisecond), tm.Add(3 * time.Millisecond)}
+	c := newTestClient(t, windowTestHandler(t, times, 1))
+
+	qp := NewQueryParameters()
+	qp.StartTime = tm.Add(500 * time.Microsecond)
+	qp.EndTime = tm.Add(3*time.Millisecond + 500*time.Microsecond)
+
+	windows, err := c.PlanTimeWindows(qp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(windows) != 3 {
+		t.Fatalf("expected 3 windows, got %d", len(windows))
+	}
+	for _, w := range windows {
+		if !w.StartTime.Equal(w.StartTime.Truncate(time.Millisecond)) || !w.EndTime.Equal(w.EndTime.Truncate(time.Millisecond)) {
+			t.Errorf("window %s - %s not aligned to milliseconds", w.StartTime.Format(time.RFC3339Nano), w.EndTime.Format(time.RFC3339Nano))
+		}
+	}
+
+}
@@ -0,0 +1,427 @@
+package pager
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/jasonmoo/usgs/earthquake"
+)
+
+// names of the contents of a losspager product
+const (
+	XMLContent           = "pager.xml"
+	AlertsJSONContent    = "json/alerts.json"
+	ExposuresJSONContent = "json/exposures.json"
+	CitiesJSONContent    = "json/cities.json"
+)
+
+const (
+	AlertTypeFatality = "fatality"
+	AlertTypeEconomic = "economic"
+)
+
+var ErrNoPager = errors.New("event has no losspager")
+
+type (
+	// Pager is the expected impact of an event as estimated by PAGER.
+	// https://earthquake.usgs.gov/data/pager/onepager.php
+	Pager struct {
+		Event     Event
+		Alerts    []Alert
+		Exposures []Exposure
+		Cities    []City
+	}
+
+	Event struct {
+		EventCode   string
+		VersionCode string
+		Magnitude   float64
+		Depth       float64
+		Latitude    float64
+		Longitude   float64
+		Timestamp   time.Time
+		Network     string
+		Description string
+	}
+
+	// a fatality or economic loss alert with the probability of each loss range
+	Alert struct {
+		Type    string
+		Level   earthquake.AlertLevel
+		Summary bool
+		Units   string
+		Bins    []AlertBin
+	}
+
+	AlertBin struct {
+		Min         float64 `json:"min"`
+		Max         float64 `json:"max"`
+		Probability float64 `json:"probability"`
+		Color       string  `json:"color"`
+	}
+
+	// population exposed to shaking between MinMMI and MaxMMI
+	Exposure struct {
+		MinMMI            float64
+		MaxMMI            float64
+		Population        float64
+		RangeInsufficient bool
+	}
+
+	City struct {
+		Name       string
+		Latitude   float64
+		Longitude  float64
+		Population float64
+		MMI        float64
+		IsCapital  bool
+	}
+)
+
+func parseBool(s string) bool {
+	switch strings.ToLower(s) {
+	case "1", "yes", "true":
+		return true
+	}
+	return false
+}
+
+// the alert of type t, or nil
+func (p *Pager) Alert(t string) *Alert {
+	for i := range p.Alerts {
+		if p.Alerts[i].Type == t {
+			return &p.Alerts[i]
+		}
+	}
+	return nil
+}
+
+// the estimated fatality alert, or nil
+func (p *Pager) FatalityAlert() *Alert { return p.Alert(AlertTypeFatality) }
+
+// the estimated economic loss alert, or nil
+func (p *Pager) EconomicAlert() *Alert { return p.Alert(AlertTypeEconomic) }
+
+// the population exposed to shaking of intensity mmi, rounded to the nearest bin
+func (p *Pager) ExposureAt(mmi float64) float64 {
+	for _, e := range p.Exposures {
+		if mmi >= e.MinMMI && mmi < e.MaxMMI {
+			return e.Population
+		}
+	}
+	return 0
+}
+
+// the bin of the alert most likely to contain the loss, or nil
+func (a *Alert) MostLikely() *AlertBin {
+	var bin *AlertBin
+	for i := range a.Bins {
+		if bin == nil || a.Bins[i].Probability > bin.Probability {
+			bin = &a.Bins[i]
+		}
+	}
+	return bin
+}
+
+// parse a pager.xml document.
+func ParseXML(r io.Reader) (*Pager, error) {
+
+	type (
+		xmlAlert struct {
+			Type    string `xml:"type,attr"`
+			Level   string `xml:"level,attr"`
+			Summary string `xml:"summary,attr"`
+			Units   string `xml:"units,attr"`
+			Bins    []struct {
+				Min         float64 `xml:"min,attr"`
+				Max         float64 `xml:"max,attr"`
+				Probability float64 `xml:"probability,attr"`
+				Color       string  `xml:"color,attr"`
+			} `xml:"bin"`
+		}
+		xmlExposure struct {
+			Dmin              float64 `xml:"dmin,attr"`
+			Dmax              float64 `xml:"dmax,attr"`
+			Exposure          float64 `xml:"exposure,attr"`
+			RangeInsufficient string  `xml:"rangeInsufficient,attr"`
+		}
+		xmlCity struct {
+			Name       string  `xml:"name,attr"`
+			Lat        float64 `xml:"lat,attr"`
+			Lon        float64 `xml:"lon,attr"`
+			Population float64 `xml:"population,attr"`
+			MMI        float64 `xml:"mmi,attr"`
+			IsCapital  string  `xml:"iscapital,attr"`
+		}
+	)
+
+	// alerts, exposures and cities appear both bare and wrapped
+	// depending on the version of PAGER that produced the document
+	var doc struct {
+		Event struct {
+			EventCode   string  `xml:"eventcode,attr"`
+			VersionCode string  `xml:"versioncode,attr"`
+			Magnitude   float64 `xml:"magnitude,attr"`
+			Depth       float64 `xml:"depth,attr"`
+			Lat         float64 `xml:"lat,attr"`
+			Lon         float64 `xml:"lon,attr"`
+			Timestamp   string  `xml:"event_timestamp,attr"`
+			Network     string  `xml:"event_network,attr"`
+			Description string  `xml:"event_description,attr"`
+		} `xml:"event"`
+		Alerts           []xmlAlert    `xml:"alert"`
+		WrappedAlerts    []xmlAlert    `xml:"alerts>alert"`
+		Exposures        []xmlExposure `xml:"exposure"`
+		WrappedExposures []xmlExposure `xml:"exposures>exposure"`
+		Cities           []xmlCity     `xml:"city"`
+		WrappedCities    []xmlCity     `xml:"cities>city"`
+	}
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	p := &Pager{
+		Event: Event{
+			EventCode:   doc.Event.EventCode,
+			VersionCode: doc.Event.VersionCode,
+			Magnitude:   doc.Event.Magnitude,
+			Depth:       doc.Event.Depth,
+			Latitude:    doc.Event.Lat,
+			Longitude:   doc.Event.Lon,
+			Network:     doc.Event.Network,
+			Description: doc.Event.Description,
+		},
+	}
+	var err error
+	if p.Event.Timestamp, err = parseTimestamp(doc.Event.Timestamp); err != nil {
+		return nil, fmt.Errorf("invalid event_timestamp: %v", err)
+	}
+
+	for _, a := range append(doc.Alerts, doc.WrappedAlerts...) {
+		alert := Alert{
+			Type:    a.Type,
+			Level:   earthquake.AlertLevel(a.Level),
+			Summary: parseBool(a.Summary),
+			Units:   a.Units,
+		}
+		for _, b := range a.Bins {
+			alert.Bins = append(alert.Bins, AlertBin(b))
+		}
+		p.Alerts = append(p.Alerts, alert)
+	}
+	for _, e := range append(doc.Exposures, doc.WrappedExposures...) {
+		p.Exposures = append(p.Exposures, Exposure{
+			MinMMI:            e.Dmin,
+			MaxMMI:            e.Dmax,
+			Population:        e.Exposure,
+			RangeInsufficient: parseBool(e.RangeInsufficient),
+		})
+	}
+	for _, c := range append(doc.Cities, doc.WrappedCities...) {
+		p.Cities = append(p.Cities, City{
+			Name:       c.Name,
+			Latitude:   c.Lat,
+			Longitude:  c.Lon,
+			Population: c.Population,
+			MMI:        c.MMI,
+			IsCapital:  parseBool(c.IsCapital),
+		})
+	}
+
+	return p, nil
+
+}
+
+// parse the json/alerts.json content of a losspager product
+//
+//	{"fatality": {"level": "green", "units": "fatalities", "bins": [{"min": 0, "max": 1, "probability": 0.6, "color": "green"}, ...]}, "economic": {...}}
+func ParseAlertsJSON(r io.Reader) ([]Alert, error) {
+
+	var doc map[string]struct {
+		Level string     `json:"level"`
+		Units string     `json:"units"`
+		Bins  []AlertBin `json:"bins"`
+	}
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	var alerts []Alert
+	for _, t := range []string{AlertTypeFatality, AlertTypeEconomic} {
+		if a, exists := doc[t]; exists {
+			alerts = append(alerts, Alert{
+				Type:  t,
+				Level: earthquake.AlertLevel(a.Level),
+				Units: a.Units,
+				Bins:  a.Bins,
+			})
+		}
+	}
+
+	return alerts, nil
+
+}
+
+// parse the json/exposures.json content of a losspager product, where the
+// aggregated population exposure is listed for MMI 1 through 10
+//
+//	{"population_exposure": {"mmi": [1, 2, ..., 10], "aggregated_exposure": [0, 0, ...]}, ...}
+func ParseExposuresJSON(r io.Reader) ([]Exposure, error) {
+
+	var doc struct {
+		PopulationExposure struct {
+			MMI                []float64 `json:"mmi"`
+			AggregatedExposure []float64 `json:"aggregated_exposure"`
+		} `json:"population_exposure"`
+	}
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	pe := doc.PopulationExposure
+	if len(pe.MMI) != len(pe.AggregatedExposure) {
+		return nil, errors.New("exposures: mmi and aggregated_exposure lengths differ")
+	}
+
+	exposures := make([]Exposure, len(pe.MMI))
+	for i, mmi := range pe.MMI {
+		exposures[i] = Exposure{
+			MinMMI:     mmi - 0.5,
+			MaxMMI:     mmi + 0.5,
+			Population: pe.AggregatedExposure[i],
+		}
+	}
+
+	return exposures, nil
+
+}
+
+// parse the json/cities.json content of a losspager product
+//
+//	[{"name": "Ollague", "lat": -21.22, "lon": -68.25, "pop": 332, "mmi": 4.1, "iscap": 0}, ...]
+func ParseCitiesJSON(r io.Reader) ([]City, error) {
+
+	var doc []struct {
+		Name  string  `json:"name"`
+		Lat   float64 `json:"lat"`
+		Lon   float64 `json:"lon"`
+		Pop   float64 `json:"pop"`
+		MMI   float64 `json:"mmi"`
+		IsCap int     `json:"iscap"`
+	}
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	cities := make([]City, len(doc))
+	for i, c := range doc {
+		cities[i] = City{
+			Name:       c.Name,
+			Latitude:   c.Lat,
+			Longitude:  c.Lon,
+			Population: c.Pop,
+			MMI:        c.MMI,
+			IsCapital:  c.IsCap != 0,
+		}
+	}
+
+	return cities, nil
+
+}
+
+// fetch and parse the preferred losspager of an event, from pager.xml when
+// available and the json contents otherwise.
+func Fetch(ctx context.Context, c *earthquake.Client, e *earthquake.EventDetail) (*Pager, error) {
+
+	p := e.PreferredProduct(earthquake.ProductTypeLosspager)
+	if p == nil {
+		return nil, ErrNoPager
+	}
+
+	download := func(name string) (*bytes.Buffer, error) {
+		var buf bytes.Buffer
+		if _, err := c.DownloadContentContext(ctx, p, name, &buf); err != nil {
+			return nil, err
+		}
+		return &buf, nil
+	}
+
+	if _, exists := p.Contents[XMLContent]; exists {
+		buf, err := download(XMLContent)
+		if err != nil {
+			return nil, err
+		}
+		return ParseXML(buf)
+	}
+
+	pager := &Pager{
+		Event: Event{
+			EventCode:   e.ID,
+			Description: e.Properties.Place,
+			Latitude:    e.Geometry.Latitude(),
+			Longitude:   e.Geometry.Longitude(),
+			Depth:       e.Geometry.Depth(),
+			Timestamp:   e.Properties.Time.Time,
+			Network:     e.Properties.Net,
+		},
+	}
+	if e.Properties.Mag != nil {
+		pager.Event.Magnitude = *e.Properties.Mag
+	}
+
+	buf, err := download(AlertsJSONContent)
+	if err != nil {
+		return nil, err
+	}
+	if pager.Alerts, err = ParseAlertsJSON(buf); err != nil {
+		return nil, err
+	}
+	if _, exists := p.Contents[ExposuresJSONContent]; exists {
+		if buf, err = download(ExposuresJSONContent); err != nil {
+			return nil, err
+		}
+		if pager.Exposures, err = ParseExposuresJSON(buf); err != nil {
+			return nil, err
+		}
+	}
+	if _, exists := p.Contents[CitiesJSONContent]; exists {
+		if buf, err = download(CitiesJSONContent); err != nil {
+			return nil, err
+		}
+		if pager.Cities, err = ParseCitiesJSON(buf); err != nil {
+			return nil, err
+		}
+	}
+
+	return pager, nil
+
+}
+
+// timestamps are usually written without a zone and are UTC, older documents
+// may carry a zone abbreviation (ie: 2018-02-25T17:44:44GMT)
+var timestampLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999MST",
+	"2006-01-02 15:04:05.999999999 MST",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999",
+}
+
+func parseTimestamp(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	for _, layout := range timestampLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized timestamp %q", s)
+}
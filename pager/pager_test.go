@@ -0,0 +1,130 @@
+package pager
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jasonmoo/usgs/earthquake"
+)
+
+const testXML = `<?xml version="1.0" encoding="UTF-8"?>
+<pagerdata eventcode="us1000chhc" versioncode="us1000chhc">
+<event eventcode="us1000chhc" versioncode="us1000chhc" magnitude="7.5" depth="25.2" lat="-6.0699" lon="142.7536" event_timestamp="2018-02-25T17:44:44" event_network="us" event_description="PAPUA NEW GUINEA" />
+<alerts>
+<alert type="economic" level="orange" summary="yes" units="USD">
+<bin min="0" max="1" probability="0.05" color="green" />
+<bin min="1" max="10" probability="0.2" color="yellow" />
+<bin min="10" max="100" probability="0.35" color="orange" />
+</alert>
+<alert type="fatality" level="red" summary="no" units="fatalities">
+<bin min="100" max="1000" probability="0.4" color="red" />
+</alert>
+</alerts>
+<exposure dmin="0.5" dmax="1.5" exposure="0" rangeInsufficient="0" />
+<exposure dmin="7.5" dmax="8.5" exposure="101000" rangeInsufficient="0" />
+<exposure dmin="8.5" dmax="9.5" exposure="3000" rangeInsufficient="1" />
+<city id="2093685" name="Komo" lat="-6.0654" lon="142.8576" population="1500" mmi="8.9" iscapital="0" />
+<city id="2088122" name="Port Moresby" lat="-9.4431" lon="147.1797" population="283733" mmi="3.2" iscapital="1" />
+</pagerdata>`
+
+func TestParseXML(t *testing.T) {
+
+	p, err := ParseXML(strings.NewReader(testXML))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if p.Event.EventCode != "us1000chhc" || p.Event.Magnitude != 7.5 {
+		t.Errorf("unexpected event %+v", p.Event)
+	}
+	if !p.Event.Timestamp.Equal(time.Date(2018, 2, 25, 17, 44, 44, 0, time.UTC)) {
+		t.Errorf("unexpected timestamp %v", p.Event.Timestamp)
+	}
+
+	if a := p.FatalityAlert(); a == nil || a.Level != earthquake.AlertLevelRed || a.Summary || len(a.Bins) != 1 {
+		t.Errorf("unexpected fatality alert %+v", a)
+	}
+	a := p.EconomicAlert()
+	if a == nil || a.Level != earthquake.AlertLevelOrange || !a.Summary || a.Units != "USD" {
+		t.Fatalf("unexpected economic alert %+v", a)
+	}
+	if b := a.MostLikely(); b == nil || b.Color != "orange" {
+		t.Errorf("unexpected most likely bin %+v", b)
+	}
+
+	if n := p.ExposureAt(8); n != 101000 {
+		t.Errorf("expected 101000, got %v", n)
+	}
+	if !p.Exposures[2].RangeInsufficient {
+		t.Error("expected range insufficient")
+	}
+
+	if len(p.Cities) != 2 || p.Cities[0].Name != "Komo" || !p.Cities[1].IsCapital {
+		t.Errorf("unexpected cities %+v", p.Cities)
+	}
+
+	doc := strings.Replace(testXML, `event_timestamp="2018-02-25T17:44:44"`, `event_timestamp="2018-02-25T17:44:44GMT"`, 1)
+	if p, err := ParseXML(strings.NewReader(doc)); err != nil {
+		t.Error(err)
+	} else if !p.Event.Timestamp.Equal(time.Date(2018, 2, 25, 17, 44, 44, 0, time.UTC)) {
+		t.Errorf("unexpected timestamp %v", p.Event.Timestamp)
+	}
+
+	doc = strings.Replace(testXML, `event_timestamp="2018-02-25T17:44:44"`, `event_timestamp="yesterday"`, 1)
+	if _, err := ParseXML(strings.NewReader(doc)); err == nil {
+		t.Error("expected error for invalid timestamp")
+	}
+
+}
+
+func TestFetchJSON(t *testing.T) {
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/" + AlertsJSONContent:
+			w.Write([]byte(`{"fatality":{"level":"green","units":"fatalities","bins":[{"min":0,"max":1,"probability":0.9,"color":"green"}]},"economic":{"level":"yellow","units":"USD","bins":[]}}`))
+		case "/" + ExposuresJSONContent:
+			w.Write([]byte(`{"population_exposure":{"mmi":[1,2,3,4,5,6,7,8,9,10],"aggregated_exposure":[0,0,100,2000,30000,0,0,0,0,0]}}`))
+		case "/" + CitiesJSONContent:
+			w.Write([]byte(`[{"name":"Ollague","lat":-21.22,"lon":-68.25,"pop":332,"mmi":4.1,"iscap":0}]`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+
+	e := &earthquake.EventDetail{ID: "us2000ck9q"}
+	if _, err := Fetch(context.Background(), earthquake.NewClient(), e); err != ErrNoPager {
+		t.Errorf("expected %v, got %v", ErrNoPager, err)
+	}
+
+	contents := make(map[string]earthquake.Content)
+	for _, name := range []string{AlertsJSONContent, ExposuresJSONContent, CitiesJSONContent} {
+		contents[name] = earthquake.Content{URL: ts.URL + "/" + name}
+	}
+	e.Properties.Products = map[earthquake.ProductType][]earthquake.Product{
+		earthquake.ProductTypeLosspager: {{ID: "pager", Contents: contents}},
+	}
+
+	p, err := Fetch(context.Background(), earthquake.NewClient(), e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Event.EventCode != "us2000ck9q" {
+		t.Errorf("unexpected event %+v", p.Event)
+	}
+	if a := p.EconomicAlert(); a == nil || a.Level != earthquake.AlertLevelYellow {
+		t.Errorf("unexpected economic alert %+v", a)
+	}
+	if n := p.ExposureAt(5); n != 30000 {
+		t.Errorf("expected 30000, got %v", n)
+	}
+	if len(p.Cities) != 1 || p.Cities[0].Population != 332 {
+		t.Errorf("unexpected cities %+v", p.Cities)
+	}
+
+}
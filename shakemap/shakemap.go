@@ -0,0 +1,291 @@
+package shakemap
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jasonmoo/usgs/earthquake"
+)
+
+// name of the grid content of a shakemap product
+const GridContent = "download/grid.xml"
+
+var (
+	ErrNoShakemap = errors.New("event has no shakemap")
+	ErrOutside    = errors.New("location outside of grid")
+)
+
+type (
+	// Grid is a parsed ShakeMap grid.xml, intensity measures sampled on a regular
+	// lat/lon grid.
+	// https://usgs.github.io/shakemap/manual4_0/ug_products.html#xml-grid
+	Grid struct {
+		EventID          string
+		ShakemapID       string
+		ShakemapVersion  string
+		ProcessTimestamp time.Time
+		Originator       string
+		MapStatus        string
+		EventType        string
+
+		Event         Event
+		Specification Specification
+		Fields        []Field
+
+		// rows of nlon points from lat_max to lat_min, each point holding a
+		// value for every field
+		data []float64
+	}
+
+	Event struct {
+		EventID     string    `xml:"event_id,attr"`
+		Magnitude   float64   `xml:"magnitude,attr"`
+		Depth       float64   `xml:"depth,attr"`
+		Latitude    float64   `xml:"lat,attr"`
+		Longitude   float64   `xml:"lon,attr"`
+		Timestamp   time.Time `xml:"-"`
+		Network     string    `xml:"event_network,attr"`
+		Description string    `xml:"event_description,attr"`
+	}
+
+	Specification struct {
+		LonMin     float64 `xml:"lon_min,attr"`
+		LatMin     float64 `xml:"lat_min,attr"`
+		LonMax     float64 `xml:"lon_max,attr"`
+		LatMax     float64 `xml:"lat_max,attr"`
+		LonSpacing float64 `xml:"nominal_lon_spacing,attr"`
+		LatSpacing float64 `xml:"nominal_lat_spacing,attr"`
+		NLon       int     `xml:"nlon,attr"`
+		NLat       int     `xml:"nlat,attr"`
+	}
+
+	// ie: LON, LAT, MMI, PGA (pctg), PGV (cms), PSA03 (pctg), SVEL
+	Field struct {
+		Index int    `xml:"index,attr"`
+		Name  string `xml:"name,attr"`
+		Units string `xml:"units,attr"`
+	}
+)
+
+// parse a grid.xml document.
+func Parse(r io.Reader) (*Grid, error) {
+
+	var doc struct {
+		EventID          string `xml:"event_id,attr"`
+		ShakemapID       string `xml:"shakemap_id,attr"`
+		ShakemapVersion  string `xml:"shakemap_version,attr"`
+		ProcessTimestamp string `xml:"process_timestamp,attr"`
+		Originator       string `xml:"shakemap_originator,attr"`
+		MapStatus        string `xml:"map_status,attr"`
+		EventType        string `xml:"shakemap_event_type,attr"`
+		Event            struct {
+			Event
+			Timestamp string `xml:"event_timestamp,attr"`
+		} `xml:"event"`
+		Specification Specification `xml:"grid_specification"`
+		Fields        []Field       `xml:"grid_field"`
+		Data          []byte        `xml:"grid_data"`
+	}
+	dec := xml.NewDecoder(r)
+	dec.CharsetReader = charsetReader
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	g := &Grid{
+		EventID:         doc.EventID,
+		ShakemapID:      doc.ShakemapID,
+		ShakemapVersion: doc.ShakemapVersion,
+		Originator:      doc.Originator,
+		MapStatus:       doc.MapStatus,
+		EventType:       doc.EventType,
+		Event:           doc.Event.Event,
+		Specification:   doc.Specification,
+		Fields:          doc.Fields,
+	}
+	var err error
+	if g.ProcessTimestamp, err = parseTimestamp(doc.ProcessTimestamp); err != nil {
+		return nil, fmt.Errorf("invalid process_timestamp: %v", err)
+	}
+	if g.Event.Timestamp, err = parseTimestamp(doc.Event.Timestamp); err != nil {
+		return nil, fmt.Errorf("invalid event_timestamp: %v", err)
+	}
+
+	spec := &g.Specification
+	if spec.NLon < 1 || spec.NLat < 1 || len(g.Fields) == 0 {
+		return nil, fmt.Errorf("invalid grid specification %+v", *spec)
+	}
+	// the nominal spacing is rounded, derive it from the extent instead
+	if spec.NLon > 1 {
+		spec.LonSpacing = (spec.LonMax - spec.LonMin) / float64(spec.NLon-1)
+	}
+	if spec.NLat > 1 {
+		spec.LatSpacing = (spec.LatMax - spec.LatMin) / float64(spec.NLat-1)
+	}
+
+	nf := len(g.Fields)
+	lonf, lonok := g.field("LON")
+	latf, latok := g.field("LAT")
+	if !lonok || !latok {
+		return nil, errors.New("grid has no LON and LAT fields")
+	}
+
+	g.data = make([]float64, spec.NLon*spec.NLat*nf)
+	for i := range g.data {
+		g.data[i] = math.NaN()
+	}
+
+	values := bytes.Fields(doc.Data)
+	if len(values)%nf != 0 {
+		return nil, fmt.Errorf("grid data has %d values, not a multiple of %d fields", len(values), nf)
+	}
+
+	point := make([]float64, nf)
+	for p := 0; p < len(values); p += nf {
+		for f := range point {
+			v, err := strconv.ParseFloat(string(values[p+f]), 64)
+			if err != nil {
+				return nil, fmt.Errorf("grid data: %v", err)
+			}
+			point[f] = v
+		}
+		col, row := g.index(point[lonf], point[latf])
+		i, j := int(math.Round(col)), int(math.Round(row))
+		if i < 0 || i >= spec.NLon || j < 0 || j >= spec.NLat {
+			return nil, fmt.Errorf("grid point %v,%v outside of specification", point[lonf], point[latf])
+		}
+		copy(g.data[(j*spec.NLon+i)*nf:], point)
+	}
+
+	return g, nil
+
+}
+
+// ShakeMap 4 writes RFC3339 timestamps, legacy 3.x grids a zone abbreviation
+// (ie: 2018-01-02T03:55:52GMT) or no zone at all, which is UTC.
+var timestampLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999MST",
+	"2006-01-02 15:04:05.999999999 MST",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999",
+}
+
+func parseTimestamp(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	for _, layout := range timestampLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized timestamp %q", s)
+}
+
+// grid.xml is declared as US-ASCII, a subset of utf-8
+func charsetReader(charset string, r io.Reader) (io.Reader, error) {
+	switch strings.ToLower(charset) {
+	case "us-ascii", "ascii", "utf-8", "utf8":
+		return r, nil
+	}
+	return nil, fmt.Errorf("unsupported charset %q", charset)
+}
+
+// the position of the named field within each point, matched case insensitively
+func (g *Grid) field(name string) (int, bool) {
+	for i, f := range g.Fields {
+		if strings.EqualFold(f.Name, name) {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// fractional column and row of a location
+func (g *Grid) index(lon, lat float64) (col, row float64) {
+	spec := &g.Specification
+	if spec.NLon > 1 {
+		col = (lon - spec.LonMin) / spec.LonSpacing
+	}
+	if spec.NLat > 1 {
+		row = (spec.LatMax - lat) / spec.LatSpacing
+	}
+	return col, row
+}
+
+func (g *Grid) at(col, row, f int) float64 {
+	return g.data[(row*g.Specification.NLon+col)*len(g.Fields)+f]
+}
+
+// the value of the intensity measure, ie: "MMI", "PGA", "PSA10", at a location
+// by bilinear interpolation of the surrounding grid points.
+func (g *Grid) Value(measure string, lat, lon float64) (float64, error) {
+
+	f, ok := g.field(measure)
+	if !ok {
+		return 0, fmt.Errorf("grid has no field %q", measure)
+	}
+
+	spec := &g.Specification
+
+	// grids may cross the date line
+	switch {
+	case lon < spec.LonMin && lon+360 <= spec.LonMax:
+		lon += 360
+	case lon > spec.LonMax && lon-360 >= spec.LonMin:
+		lon -= 360
+	}
+
+	const epsilon = 1e-9
+	if lon < spec.LonMin-epsilon || lon > spec.LonMax+epsilon || lat < spec.LatMin-epsilon || lat > spec.LatMax+epsilon {
+		return 0, ErrOutside
+	}
+
+	x, y := g.index(lon, lat)
+	x = math.Max(0, math.Min(x, float64(spec.NLon-1)))
+	y = math.Max(0, math.Min(y, float64(spec.NLat-1)))
+
+	i0, j0 := int(x), int(y)
+	i1, j1 := i0, j0
+	if i0 < spec.NLon-1 {
+		i1++
+	}
+	if j0 < spec.NLat-1 {
+		j1++
+	}
+	fx, fy := x-float64(i0), y-float64(j0)
+
+	v := g.at(i0, j0, f)*(1-fx)*(1-fy) +
+		g.at(i1, j0, f)*fx*(1-fy) +
+		g.at(i0, j1, f)*(1-fx)*fy +
+		g.at(i1, j1, f)*fx*fy
+
+	return v, nil
+
+}
+
+// fetch and parse the grid of the preferred shakemap of an event
+func FetchGrid(ctx context.Context, c *earthquake.Client, e *earthquake.EventDetail) (*Grid, error) {
+
+	p := e.PreferredProduct(earthquake.ProductTypeShakemap)
+	if p == nil {
+		return nil, ErrNoShakemap
+	}
+
+	var buf bytes.Buffer
+	if _, err := c.DownloadContentContext(ctx, p, GridContent, &buf); err != nil {
+		return nil, err
+	}
+
+	return Parse(&buf)
+
+}
@@ -0,0 +1,141 @@
+package shakemap
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jasonmoo/usgs/earthquake"
+)
+
+// 3x3 grid, MMI increases 1 per column east and 3 per row south
+const testGrid = `<?xml version="1.0" encoding="US-ASCII" standalone="yes"?>
+<shakemap_grid xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xmlns="http://earthquake.usgs.gov/eqcenter/shakemap" event_id="us2000ck9q" shakemap_id="us2000ck9q" shakemap_version="2" code_version="4.0" process_timestamp="2018-01-02T05:00:00Z" shakemap_originator="us" map_status="RELEASED" shakemap_event_type="ACTUAL">
+<event event_id="us2000ck9q" magnitude="4.3" depth="117.92" lat="-21.1179" lon="-68.7707" event_timestamp="2018-01-02T03:55:52Z" event_network="us" event_description="52km SSE of Ollague, Chile" />
+<grid_specification lon_min="-70.0" lat_min="-22.0" lon_max="-69.0" lat_max="-21.0" nominal_lon_spacing="0.5" nominal_lat_spacing="0.5" nlon="3" nlat="3" />
+<grid_field index="1" name="LON" units="dd" />
+<grid_field index="2" name="LAT" units="dd" />
+<grid_field index="3" name="MMI" units="intensity" />
+<grid_field index="4" name="PGA" units="pctg" />
+<grid_data>
+-70.0 -21.0 1 0.1
+-69.5 -21.0 2 0.2
+-69.0 -21.0 3 0.3
+-70.0 -21.5 4 0.4
+-69.5 -21.5 5 0.5
+-69.0 -21.5 6 0.6
+-70.0 -22.0 7 0.7
+-69.5 -22.0 8 0.8
+-69.0 -22.0 9 0.9
+</grid_data>
+</shakemap_grid>`
+
+func TestParse(t *testing.T) {
+
+	g, err := Parse(strings.NewReader(testGrid))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if g.EventID != "us2000ck9q" || g.Event.Magnitude != 4.3 || g.ProcessTimestamp.IsZero() {
+		t.Errorf("unexpected grid header %+v", g)
+	}
+	if len(g.Fields) != 4 || g.Fields[3].Units != "pctg" {
+		t.Errorf("unexpected fields %+v", g.Fields)
+	}
+
+	for _, tc := range []struct {
+		measure  string
+		lat, lon float64
+		expected float64
+	}{
+		{"MMI", -21.0, -70.0, 1},
+		{"MMI", -22.0, -69.0, 9},
+		{"mmi", -21.5, -69.5, 5},
+		{"MMI", -21.0, -69.75, 1.5},
+		{"MMI", -21.25, -70.0, 2.5},
+		{"MMI", -21.25, -69.75, 3},
+		{"PGA", -21.75, -69.25, 0.7},
+	} {
+		v, err := g.Value(tc.measure, tc.lat, tc.lon)
+		if err != nil {
+			t.Errorf("%s %v,%v: %v", tc.measure, tc.lat, tc.lon, err)
+			continue
+		}
+		if math.Abs(v-tc.expected) > 1e-9 {
+			t.Errorf("%s %v,%v: expected %v, got %v", tc.measure, tc.lat, tc.lon, tc.expected, v)
+		}
+	}
+
+	if _, err := g.Value("MMI", -20, -70); err != ErrOutside {
+		t.Errorf("expected %v, got %v", ErrOutside, err)
+	}
+	if _, err := g.Value("PGV", -21, -70); err == nil {
+		t.Error("expected error for missing field")
+	}
+
+}
+
+func TestParseTimestamps(t *testing.T) {
+
+	expected := time.Date(2018, 1, 2, 3, 55, 52, 0, time.UTC)
+
+	for _, ts := range []string{
+		"2018-01-02T03:55:52Z",
+		"2018-01-02T03:55:52GMT",
+		"2018-01-02T03:55:52UTC",
+		"2018-01-02 03:55:52 GMT",
+		"2018-01-02T03:55:52",
+	} {
+		doc := strings.Replace(testGrid, `event_timestamp="2018-01-02T03:55:52Z"`, `event_timestamp="`+ts+`"`, 1)
+		doc = strings.Replace(doc, `process_timestamp="2018-01-02T05:00:00Z"`, `process_timestamp="`+ts+`"`, 1)
+		g, err := Parse(strings.NewReader(doc))
+		if err != nil {
+			t.Errorf("%s: %v", ts, err)
+			continue
+		}
+		if !g.Event.Timestamp.Equal(expected) || !g.ProcessTimestamp.Equal(expected) {
+			t.Errorf("%s: expected %v, got %v and %v", ts, expected, g.Event.Timestamp, g.ProcessTimestamp)
+		}
+	}
+
+	doc := strings.Replace(testGrid, `event_timestamp="2018-01-02T03:55:52Z"`, `event_timestamp="yesterday"`, 1)
+	if _, err := Parse(strings.NewReader(doc)); err == nil {
+		t.Error("expected error for invalid timestamp")
+	}
+
+}
+
+func TestFetchGrid(t *testing.T) {
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(testGrid))
+	}))
+	defer ts.Close()
+
+	e := &earthquake.EventDetail{}
+	if _, err := FetchGrid(context.Background(), earthquake.NewClient(), e); err != ErrNoShakemap {
+		t.Errorf("expected %v, got %v", ErrNoShakemap, err)
+	}
+
+	e.Properties.Products = map[earthquake.ProductType][]earthquake.Product{
+		earthquake.ProductTypeShakemap: {{
+			ID:       "urn:usgs-product:us:shakemap:us2000ck9q:1",
+			Contents: map[string]earthquake.Content{GridContent: {URL: fmt.Sprintf("%s/%s", ts.URL, GridContent)}},
+		}},
+	}
+
+	g, err := FetchGrid(context.Background(), earthquake.NewClient(), e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, err := g.Value("MMI", -21.5, -69.5); err != nil || v != 5 {
+		t.Errorf("expected 5, got %v %v", v, err)
+	}
+
+}